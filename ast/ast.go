@@ -8,6 +8,7 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
 }
 
 type Statement interface {
@@ -32,6 +33,13 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -53,6 +61,10 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -69,6 +81,10 @@ func (i *TypedIdentifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *TypedIdentifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
 func (i *TypedIdentifier) String() string {
 	return i.Value
 }
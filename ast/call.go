@@ -19,6 +19,10 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Token.Pos
+}
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -28,6 +32,7 @@ func (ce *CallExpression) String() string {
 	}
 	if ce.Outer != nil {
 		out.WriteString(ce.Outer.String())
+		out.WriteString(".")
 	}
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
@@ -10,7 +10,14 @@ type ClassLiteral struct {
 	Token token.Token
 	Name *Identifier
 	Parameters []*TypedIdentifier
+	// Parent is the class being extended, if any. ParentArguments are passed to its
+	// constructor, or nil if the class's own arguments are passed on unchanged.
+	Parent *Identifier
+	ParentArguments []Expression
+	Interfaces []*Identifier
 	Body *BlockStatement
+	// Doc is the text of the comments directly before the class, if any
+	Doc string
 }
 
 func (cl *ClassLiteral) expressionNode() {}
@@ -19,6 +26,10 @@ func (cl *ClassLiteral) TokenLiteral() string {
 	return cl.Token.Literal
 }
 
+func (cl *ClassLiteral) Pos() token.Position {
+	return cl.Token.Pos
+}
+
 func (cl *ClassLiteral) String() string {
 	var out bytes.Buffer
 
@@ -31,7 +42,26 @@ func (cl *ClassLiteral) String() string {
 	out.WriteString(cl.Name.Value)
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") {")
+	out.WriteString(")")
+	if cl.Parent != nil {
+		out.WriteString(" extends ")
+		out.WriteString(cl.Parent.Value)
+		if cl.ParentArguments != nil {
+			args := []string{}
+			for _, a := range cl.ParentArguments {
+				args = append(args, a.String())
+			}
+			out.WriteString("(" + strings.Join(args, ", ") + ")")
+		}
+	}
+	if len(cl.Interfaces) > 0 {
+		names := []string{}
+		for _, i := range cl.Interfaces {
+			names = append(names, i.Value)
+		}
+		out.WriteString(" implements " + strings.Join(names, ", "))
+	}
+	out.WriteString(" {")
 	out.WriteString(cl.Body.String())
 	out.WriteString("}")
 
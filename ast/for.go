@@ -18,6 +18,10 @@ func (fl *ForLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+func (fl *ForLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
 func (fl *ForLiteral) String() string {
 	var out bytes.Buffer
 
@@ -6,20 +6,37 @@ import (
 	"strings"
 )
 
+// FunctionLiteral defines a function. An anonymous function has no Name, and isn't
+// bound to one when it is defined.
 type FunctionLiteral struct {
 	Token      token.Token
 	Name       *Identifier
 	Parameters []*TypedIdentifier
 	Body       *BlockStatement
 	ReturnType *Identifier
+	// Doc is the text of the comments directly before the function, if any
+	Doc string
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
 
+// FunctionName gives the name the function is called by in a call stack, which is
+// <lambda> for an anonymous function
+func (fl *FunctionLiteral) FunctionName() string {
+	if fl.Name == nil {
+		return "<lambda>"
+	}
+	return fl.Name.Value
+}
+
 func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -29,11 +46,13 @@ func (fl *FunctionLiteral) String() string {
 	}
 
 	out.WriteString(fl.TokenLiteral())
-	out.WriteString(fl.Name.Value)
+	if fl.Name != nil {
+		out.WriteString(fl.Name.Value)
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
-	out.WriteString(": " + fl.ReturnType.Value)
+	out.WriteString(": " + TypeString(fl.ReturnType))
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -5,9 +5,12 @@ import (
 	"bytes"
 )
 
+// ImportStatement imports a module. Its names are used through Alias, or through the
+// module's own name if no alias is given.
 type ImportStatement struct {
 	Token token.Token
 	Value Expression
+	Alias *Identifier
 }
 
 func (is *ImportStatement) statementNode() {}
@@ -16,11 +19,18 @@ func (is *ImportStatement) TokenLiteral() string {
 	return is.Token.Literal
 }
 
+func (is *ImportStatement) Pos() token.Position {
+	return is.Token.Pos
+}
+
 func (is *ImportStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(is.TokenLiteral() + " ")
 	out.WriteString(is.Value.String())
+	if is.Alias != nil {
+		out.WriteString(" as " + is.Alias.Value)
+	}
 	out.WriteString(";")
 
 	return out.String()
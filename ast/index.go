@@ -17,6 +17,10 @@ func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -28,3 +32,68 @@ func (ie *IndexExpression) String() string {
 
 	return out.String()
 }
+
+// SliceExpression takes part of an array or string, as in xs[1:3]. Start and End are nil
+// where they're left out, as in xs[:3] or xs[1:].
+type SliceExpression struct {
+	Token token.Token
+	Left  Expression
+	Start Expression
+	End   Expression
+}
+
+func (se *SliceExpression) expressionNode() {}
+
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SliceExpression) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// IndexAssignment sets an element of an array or hashmap, as in xs[0] = 5; or xs[0] += 5;
+type IndexAssignment struct {
+	Token token.Token
+	Index *IndexExpression
+	// Operator is the infix operator a compound assignment applies, or "" for =
+	Operator string
+	Value    Expression
+}
+
+func (ia *IndexAssignment) statementNode() {}
+
+func (ia *IndexAssignment) TokenLiteral() string {
+	return ia.Token.Literal
+}
+
+func (ia *IndexAssignment) Pos() token.Position {
+	return ia.Index.Pos()
+}
+
+func (ia *IndexAssignment) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ia.Index.String() + " " + ia.Operator + "= ")
+	out.WriteString(ia.Value.String() + ";")
+
+	return out.String()
+}
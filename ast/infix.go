@@ -18,6 +18,10 @@ func (oe *InfixExpression) TokenLiteral() string {
 	return oe.Token.Literal
 }
 
+func (oe *InfixExpression) Pos() token.Position {
+	return oe.Token.Pos
+}
+
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 
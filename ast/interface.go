@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"bytes"
+	"github.com/OisinA/Azula/token"
+	"strings"
+)
+
+// InterfaceLiteral declares the methods a class must have to implement the interface
+type InterfaceLiteral struct {
+	Token   token.Token
+	Name    *Identifier
+	Methods []*MethodSignature
+	// Doc is the text of the comments directly before the interface, if any
+	Doc string
+}
+
+func (il *InterfaceLiteral) expressionNode() {}
+
+func (il *InterfaceLiteral) TokenLiteral() string {
+	return il.Token.Literal
+}
+
+func (il *InterfaceLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
+func (il *InterfaceLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(il.TokenLiteral() + " ")
+	out.WriteString(il.Name.Value)
+	out.WriteString(" {")
+	for _, m := range il.Methods {
+		out.WriteString(" " + m.String())
+	}
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// MethodSignature is a method of an interface, which has no body
+type MethodSignature struct {
+	Token      token.Token
+	Name       *Identifier
+	Parameters []*TypedIdentifier
+	ReturnType *Identifier
+}
+
+func (ms *MethodSignature) TokenLiteral() string {
+	return ms.Token.Literal
+}
+
+func (ms *MethodSignature) Pos() token.Position {
+	return ms.Token.Pos
+}
+
+func (ms *MethodSignature) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ms.Parameters {
+		params = append(params, TypeString(&p.ReturnType)+" "+p.Value)
+	}
+
+	out.WriteString(ms.TokenLiteral() + " ")
+	out.WriteString(ms.Name.Value)
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString("): ")
+	out.WriteString(TypeString(ms.ReturnType))
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// TypeString gives the name of a type as it is written in a program, as in array(int)
+func TypeString(t *Identifier) string {
+	// a function type's Value is already the whole type
+	if t.Token.Literal == t.Value || t.Token.Type == token.FUNCTION {
+		return t.Value
+	}
+	return t.Token.Literal + "(" + t.Value + ")"
+}
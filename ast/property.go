@@ -0,0 +1,61 @@
+package ast
+
+import (
+	"bytes"
+	"github.com/OisinA/Azula/token"
+)
+
+// PropertyExpression reads a field of an instance, as in obj.x
+type PropertyExpression struct {
+	Token    token.Token
+	Object   Expression
+	Property *Identifier
+}
+
+func (pe *PropertyExpression) expressionNode() {}
+
+func (pe *PropertyExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+func (pe *PropertyExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PropertyExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(pe.Object.String())
+	out.WriteString(".")
+	out.WriteString(pe.Property.String())
+
+	return out.String()
+}
+
+// PropertyAssignment sets a field of an instance, as in obj.x = 5; or obj.x += 5;
+type PropertyAssignment struct {
+	Token    token.Token
+	Property *PropertyExpression
+	// Operator is the infix operator a compound assignment applies, or "" for =
+	Operator string
+	Value    Expression
+}
+
+func (pa *PropertyAssignment) statementNode() {}
+
+func (pa *PropertyAssignment) TokenLiteral() string {
+	return pa.Token.Literal
+}
+
+func (pa *PropertyAssignment) Pos() token.Position {
+	return pa.Property.Pos()
+}
+
+func (pa *PropertyAssignment) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(pa.Property.String() + " " + pa.Operator + "= ")
+	out.WriteString(pa.Value.String() + ";")
+
+	return out.String()
+}
@@ -5,9 +5,12 @@ import (
 	"bytes"
 )
 
+// ReassignStatement sets a variable, as in x = 5; or with an operator, as in x += 5;
 type ReassignStatement struct {
 	Token token.Token
 	Name *Identifier
+	// Operator is the infix operator a compound assignment applies, or "" for =
+	Operator string
 	Value Expression
 }
 
@@ -17,10 +20,14 @@ func (rs *ReassignStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+func (rs *ReassignStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
 func (rs *ReassignStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(rs.TokenLiteral() + " = ")
+	out.WriteString(rs.TokenLiteral() + " " + rs.Operator + "= ")
 	out.WriteString(rs.Value.String() + ";")
 
 	return out.String()
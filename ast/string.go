@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"strings"
+
 	"github.com/OisinA/Azula/token"
 )
 
@@ -15,6 +17,41 @@ func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
 
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
 func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
+
+// InterpolatedString is a string with expressions in it, as in "hello ${name}". Parts
+// holds its text, as StringLiterals, and its expressions in the order they appear.
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode() {}
+
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *InterpolatedString) Pos() token.Position {
+	return is.Token.Pos
+}
+
+func (is *InterpolatedString) String() string {
+	var out strings.Builder
+
+	for _, part := range is.Parts {
+		if text, ok := part.(*StringLiteral); ok {
+			out.WriteString(text.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+
+	return out.String()
+}
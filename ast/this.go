@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"github.com/OisinA/Azula/token"
+)
+
+// ThisExpression is the instance a method was called on
+type ThisExpression struct {
+	Token token.Token
+}
+
+func (te *ThisExpression) expressionNode() {}
+
+func (te *ThisExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+
+func (te *ThisExpression) Pos() token.Position {
+	return te.Token.Pos
+}
+
+func (te *ThisExpression) String() string {
+	return te.Token.Literal
+}
+
+// SuperExpression is the part of an instance belonging to the parent class, as in super.method()
+type SuperExpression struct {
+	Token token.Token
+}
+
+func (se *SuperExpression) expressionNode() {}
+
+func (se *SuperExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SuperExpression) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SuperExpression) String() string {
+	return se.Token.Literal
+}
@@ -0,0 +1,95 @@
+package ast
+
+import (
+	"bytes"
+	"github.com/OisinA/Azula/token"
+)
+
+// TryStatement runs Body, handing any error it raises to the first catch clause that
+// matches it. Finally runs afterwards however the rest of the statement ends.
+type TryStatement struct {
+	Token   token.Token
+	Body    *BlockStatement
+	Catches []*CatchClause
+	Finally *BlockStatement
+}
+
+func (ts *TryStatement) statementNode() {}
+
+func (ts *TryStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *TryStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try {")
+	out.WriteString(ts.Body.String())
+	out.WriteString("}")
+	for _, c := range ts.Catches {
+		out.WriteString(" " + c.String())
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally {")
+		out.WriteString(ts.Finally.String())
+		out.WriteString("}")
+	}
+
+	return out.String()
+}
+
+// CatchClause handles errors of kind Kind, or every error if Kind is nil, binding the
+// error to Name
+type CatchClause struct {
+	Token token.Token
+	Kind  *Identifier
+	Name  *Identifier
+	Body  *BlockStatement
+}
+
+func (cc *CatchClause) TokenLiteral() string {
+	return cc.Token.Literal
+}
+
+func (cc *CatchClause) Pos() token.Position {
+	return cc.Token.Pos
+}
+
+func (cc *CatchClause) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("catch (")
+	if cc.Kind != nil {
+		out.WriteString(cc.Kind.Value + " ")
+	}
+	out.WriteString(cc.Name.Value)
+	out.WriteString(") {")
+	out.WriteString(cc.Body.String())
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// ThrowStatement raises an error, built from a message or an error value
+type ThrowStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *ThrowStatement) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
+}
@@ -0,0 +1,58 @@
+package ast
+
+import "strings"
+
+// Types are written out as strings, as in func(array(int), hashmap(string, A)): bool.
+// These pull them apart again, for the checker and the checks done at runtime.
+
+// SplitFunctionType gives the parameter and return types of a function type, or false
+// if t isn't one
+func SplitFunctionType(t string) ([]string, string, bool) {
+	end := closingParen(t, len("func"))
+	if !strings.HasPrefix(t, "func(") || end < 0 || !strings.HasPrefix(t[end:], "): ") {
+		return nil, "", false
+	}
+	return SplitTypes(t[len("func("):end]), t[end+len("): "):], true
+}
+
+// SplitTypes splits a list of types separated by commas, leaving the commas inside
+// types like hashmap(K, V) alone
+func SplitTypes(s string) []string {
+	types := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				types = append(types, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		types = append(types, rest)
+	}
+	return types
+}
+
+// closingParen gives the index of the parenthesis closing the one at open, or -1 if
+// there isn't one
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,36 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/OisinA/Azula/token"
+)
+
+type WhileLiteral struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (wl *WhileLiteral) expressionNode() {}
+
+func (wl *WhileLiteral) TokenLiteral() string {
+	return wl.Token.Literal
+}
+
+func (wl *WhileLiteral) Pos() token.Position {
+	return wl.Token.Pos
+}
+
+func (wl *WhileLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(wl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(wl.Condition.String())
+	out.WriteString(") {")
+	out.WriteString(wl.Body.String())
+	out.WriteString("}")
+
+	return out.String()
+}
@@ -0,0 +1,189 @@
+// Package azula runs Azula programs from inside a Go program. Each Interpreter has its
+// own globals, builtins, modules and limits, so any number of them can run at once,
+// though a single Interpreter must only be used by one goroutine at a time.
+package azula
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/OisinA/Azula/evaluator"
+	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/parser"
+	"github.com/OisinA/Azula/repl"
+	"github.com/OisinA/Azula/token"
+	"github.com/OisinA/Azula/typecheck"
+)
+
+// Interpreter runs programs in a global scope that lasts between runs, so a later
+// program sees what an earlier one defined
+type Interpreter struct {
+	env     *object.Environment
+	checker *typecheck.Checker
+}
+
+func New() *Interpreter {
+	return &Interpreter{env: object.NewEnvironment(), checker: typecheck.New()}
+}
+
+// ParseError is a program that couldn't be parsed
+type ParseError struct {
+	Errors []*parser.Error
+}
+
+func (e *ParseError) Error() string {
+	return "parser errors: " + joinErrors(len(e.Errors), func(i int) error { return e.Errors[i] })
+}
+
+// TypeError is a program that failed type checking, so wasn't run
+type TypeError struct {
+	Errors []*typecheck.Error
+}
+
+func (e *TypeError) Error() string {
+	return "type errors: " + joinErrors(len(e.Errors), func(i int) error { return e.Errors[i] })
+}
+
+func joinErrors(n int, err func(i int) error) string {
+	msgs := []string{}
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, err(i).Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// RuntimeError is an error a program raised and didn't catch
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Err.Pos.IsValid() {
+		return e.Err.Pos.String() + ": " + e.Err.Kind + ": " + e.Err.Message
+	}
+	return e.Err.Kind + ": " + e.Err.Message
+}
+
+// Run runs a program, giving the value of its last statement
+func (in *Interpreter) Run(src string) (object.Object, error) {
+	return in.run(src, "")
+}
+
+// RunFile runs the program in a file. Its imports are found relative to it.
+func (in *Interpreter) RunFile(filename string) (object.Object, error) {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return in.run(string(dat), filename)
+}
+
+func (in *Interpreter) run(src string, filename string) (object.Object, error) {
+	p := parser.New(lexer.NewWithFilename(src, filename))
+	program := p.ParseProgram()
+	if len(p.ErrorList()) != 0 {
+		return nil, &ParseError{Errors: p.ErrorList()}
+	}
+
+	// a program with type errors leaves nothing in the checker, as it isn't run
+	if errors := in.checker.Extend(program); len(errors) != 0 {
+		return nil, &TypeError{Errors: errors}
+	}
+
+	in.env.Limits().Steps = 0
+	return in.result(evaluator.Eval(program, in.env), src, filename)
+}
+
+// Call calls a function the interpreter's programs have defined, or a builtin, with
+// arguments converted from Go values by ToObject
+func (in *Interpreter) Call(name string, args ...interface{}) (object.Object, error) {
+	function, ok := in.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("identifier not found: %s", name)
+	}
+
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d to %s: %s", i+1, name, err)
+		}
+		objs[i] = obj
+	}
+	in.env.Limits().Steps = 0
+	return in.result(evaluator.CallFunction(function, objs, in.env), "", "")
+}
+
+// lookup finds a name as a program would, in its globals and then its builtins
+func (in *Interpreter) lookup(name string) (object.Object, bool) {
+	if obj, ok := in.Get(name); ok {
+		return obj, true
+	}
+	if builtin, ok := in.env.Builtin(name); ok {
+		return builtin, true
+	}
+	if builtin, ok := evaluator.LookupBuiltin(name); ok {
+		return builtin, true
+	}
+	return nil, false
+}
+
+// result gives what running a program or calling a function gave, or the error it
+// raised. The traceback of an error is written to the errors stream too, with the
+// lines of src, the source of the file filename, that it went through.
+func (in *Interpreter) result(obj object.Object, src string, filename string) (object.Object, error) {
+	if err, ok := obj.(*object.Error); ok {
+		io.WriteString(in.env.Context().Err, repl.FormatTrace(err, func(pos token.Position) string {
+			if pos.Filename == filename {
+				return src
+			}
+			dat, _ := ioutil.ReadFile(pos.Filename)
+			return string(dat)
+		}))
+		return nil, &RuntimeError{Err: err}
+	}
+	if obj == nil {
+		return evaluator.NULL, nil
+	}
+	return obj, nil
+}
+
+// Set defines a global, converting its value from a Go value by ToObject
+func (in *Interpreter) Set(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return fmt.Errorf("can't set %s: %s", name, err)
+	}
+	in.env.Set(name, obj)
+	in.checker.Define(name, typeOf(obj))
+	return nil
+}
+
+// Get gives the value of a global
+func (in *Interpreter) Get(name string) (object.Object, bool) {
+	return in.env.GetLocal(name)
+}
+
+// RegisterBuiltin adds a builtin function to the interpreter's programs, replacing the
+// language's own builtin of the same name. It is called with the arguments as they
+// are, and can return an error made by evaluator.NewError to raise it.
+func (in *Interpreter) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	in.env.SetBuiltin(name, &object.Builtin{Fn: fn})
+	in.checker.Define(name, typecheck.UNKNOWN)
+}
+
+// SetIO changes the streams the interpreter's programs read their input from and write
+// their output to, and the stream the traceback of an error a program doesn't catch is
+// written to. They are the process's own to begin with.
+func (in *Interpreter) SetIO(input io.Reader, output io.Writer, errors io.Writer) {
+	in.env.SetContext(object.NewContext(input, output, errors))
+}
+
+// Limits gives the limits on the interpreter's programs, which can be changed between
+// runs. The step limit applies to each run or call on its own.
+func (in *Interpreter) Limits() *object.Limits {
+	return in.env.Limits()
+}
@@ -0,0 +1,269 @@
+package azula
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OisinA/Azula/evaluator"
+	"github.com/OisinA/Azula/object"
+)
+
+func TestRun(t *testing.T) {
+	in := New()
+	if _, err := in.Run("int x = 5; func double(int n): int { return n * 2; }"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// a later run sees what an earlier one defined
+	result, err := in.Run("double(x) + 1;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if FromObject(result) != int64(11) {
+		t.Errorf("wrong result. got=%s, want=11", result.Inspect())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	in := New()
+	var errs bytes.Buffer
+	in.SetIO(strings.NewReader(""), ioutil.Discard, &errs)
+
+	_, err := in.Run("int x = ;")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("expected a ParseError. got=%T (%v)", err, err)
+	}
+
+	_, err = in.Run(`int x = "a";`)
+	var typeErr *TypeError
+	if !errors.As(err, &typeErr) || typeErr.Errors[0].Message != "trying to assign string to int x" {
+		t.Errorf("expected a TypeError. got=%T (%v)", err, err)
+	}
+
+	// only the errors of the latest program are given
+	_, err = in.Run("string y = 1;")
+	if !errors.As(err, &typeErr) || len(typeErr.Errors) != 1 {
+		t.Errorf("expected 1 type error. got=%v", err)
+	}
+
+	// nothing from a program with type errors is kept, as it never ran
+	if _, err = in.Run("int a = 1; string b = 2;"); err == nil {
+		t.Fatalf("expected a type error")
+	}
+	if _, err = in.Run("a + 1;"); !errors.As(err, &typeErr) {
+		t.Errorf("expected a TypeError using a. got=%T (%v)", err, err)
+	}
+
+	_, err = in.Run("[1][4];")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.INDEX_ERROR {
+		t.Errorf("expected a RuntimeError. got=%T (%v)", err, err)
+	}
+	if err.Error() != "1:4: IndexError: index out of bounds" {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+	// only an error raised while running is written to the errors stream
+	if errs.String() != "1:4: ERROR: index out of bounds\n[1][4];\n   ^\n" {
+		t.Errorf("wrong errors written. got=%q", errs.String())
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New()
+	in.SetIO(strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	if _, err := in.Run(`func greet(string name, int times): string { string s = ""; for(i in range(times)) { s = s + name; } return s; }`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := in.Call("greet", "ab", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if FromObject(result) != "ababab" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+
+	if _, err := in.Call("greet", "ab"); err == nil || err.Error() != "ArityError: wrong number of arguments to greet. got=1, want=2" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+	if _, err := in.Call("nope"); err == nil || err.Error() != "identifier not found: nope" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+
+	result, err = in.Call("len", []string{"a", "b"})
+	if err != nil || FromObject(result) != int64(2) {
+		t.Errorf("wrong result calling a builtin. got=%v, %v", result, err)
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	in := New()
+	if err := in.Set("limit", 10); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := in.Set("names", []string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := in.Run("int total = limit + len(names);"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	total, ok := in.Get("total")
+	if !ok || FromObject(total) != int64(12) {
+		t.Errorf("wrong value for total. got=%v", total)
+	}
+
+	// globals the host sets are type checked like any other
+	if _, err := in.Run(`string s = limit;`); err == nil {
+		t.Errorf("expected a type error assigning int to string")
+	}
+
+	// booleans the host makes itself act like the ones programs make
+	in.Set("yes", &object.Boolean{Value: true})
+	result, err := in.Run("!yes || !!yes && (if(yes) { true } else { false });")
+	if err != nil || FromObject(result) != true {
+		t.Errorf("wrong result for a host boolean. got=%v (%v)", result, err)
+	}
+
+	if err := in.Set("bad", struct{}{}); err == nil {
+		t.Errorf("expected an error setting a struct")
+	}
+	if _, ok := in.Get("missing"); ok {
+		t.Errorf("expected missing to be undefined")
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	in := New()
+	var logged []string
+	in.RegisterBuiltin("log", func(ctx *object.Context, args ...object.Object) object.Object {
+		for _, arg := range args {
+			logged = append(logged, arg.Inspect())
+		}
+		return evaluator.NULL
+	})
+	in.RegisterBuiltin("fail", func(ctx *object.Context, args ...object.Object) object.Object {
+		return evaluator.NewError("failed")
+	})
+
+	if _, err := in.Run(`log("a", 1); string s = ""; try { fail(); } catch (e) { s = e.message; } log(s);`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(logged, []string{"a", "1", "failed"}) {
+		t.Errorf("wrong calls to log. got=%v", logged)
+	}
+
+	// builtins are only added to the interpreter they were registered with
+	if _, err := New().Run(`log("a");`); err == nil {
+		t.Errorf("expected log to be undefined in another interpreter")
+	}
+}
+
+func TestIsolation(t *testing.T) {
+	var wg sync.WaitGroup
+	results := make([]object.Object, 8)
+	errs := make([]error, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			in := New()
+			in.RegisterBuiltin("id", func(ctx *object.Context, args ...object.Object) object.Object {
+				return &object.Integer{Value: int64(i)}
+			})
+			in.Set("n", i)
+			src := "func fib(int x): int { if(x < 2) { return x; } return fib(x - 1) + fib(x - 2); } int r = fib(15) + n * 1000 + id();"
+			if _, errs[i] = in.Run(src); errs[i] == nil {
+				results[i], _ = in.Get("r")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Errorf("interpreter %d: unexpected error: %s", i, errs[i])
+			continue
+		}
+		want := int64(610 + i*1000 + i)
+		if FromObject(result) != want {
+			t.Errorf("interpreter %d: wrong result. got=%s, want=%d", i, result.Inspect(), want)
+		}
+	}
+}
+
+func TestSetIO(t *testing.T) {
+	in := New()
+	var out bytes.Buffer
+	in.SetIO(strings.NewReader("Ada\nLovelace"), &out, ioutil.Discard)
+
+	if _, err := in.Run(`string first = input("first: "); string last = input(); print(first + " " + last);`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if out.String() != "first: Ada Lovelace\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestLimits(t *testing.T) {
+	in := New()
+	in.SetIO(strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	in.Limits().MaxSteps = 1000
+	_, err := in.Run("while(true) { 1; }")
+	if err == nil || err.Error() != "1:1: StepLimitError: maximum number of steps exceeded (1000)" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+	// each run gets the full number of steps
+	if _, err := in.Run("int x = 1;"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		inspect  string
+		expected interface{}
+	}{
+		{5, "5", int64(5)},
+		{uint8(7), "7", int64(7)},
+		{uint64(7), "7", int64(7)},
+		{uintptr(3), "3", int64(3)},
+		{uint64(1) << 63, "9223372036854775808", new(big.Int).Lsh(big.NewInt(1), 63)},
+		{2.5, "2.5", 2.5},
+		{true, "true", true},
+		{"hi", "hi", "hi"},
+		{nil, "null", nil},
+		{new(big.Int).Lsh(big.NewInt(1), 70), "1180591620717411303424", new(big.Int).Lsh(big.NewInt(1), 70)},
+		{[]int{1, 2}, "[1, 2]", []interface{}{int64(1), int64(2)}},
+		{map[string]int{"b": 2, "a": 1}, "{a: 1, b: 2}", map[interface{}]interface{}{"a": int64(1), "b": int64(2)}},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if obj.Inspect() != tt.inspect {
+			t.Errorf("%v: wrong object. got=%s, want=%s", tt.input, obj.Inspect(), tt.inspect)
+		}
+		if back := FromObject(obj); !reflect.DeepEqual(back, tt.expected) {
+			t.Errorf("%v: wrong Go value. got=%#v, want=%#v", tt.input, back, tt.expected)
+		}
+	}
+
+	for _, input := range []interface{}{[]interface{}{1, "a"}, map[string]interface{}{"a": 1, "b": "c"}, fmt.Println} {
+		if _, err := ToObject(input); err == nil {
+			t.Errorf("%T: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package azula
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/OisinA/Azula/evaluator"
+	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/typecheck"
+)
+
+// ToObject converts a Go value to the object a program sees. Integers, floats, bools,
+// strings and nil convert to the matching values, a *big.Int, or an unsigned integer too
+// large for an int, to a bigint, slices to arrays and maps to hashmaps, whose elements
+// must all be of one type. An object is given as it is.
+func ToObject(v interface{}) (object.Object, error) {
+	if obj, ok := v.(object.Object); ok {
+		return obj, nil
+	}
+	if v == nil {
+		return evaluator.NULL, nil
+	}
+	if i, ok := v.(*big.Int); ok {
+		return &object.BigInt{Value: new(big.Int).Set(i)}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: rv.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &object.Integer{Value: int64(rv.Uint())}, nil
+	case reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > math.MaxInt64 {
+			return &object.BigInt{Value: new(big.Int).SetUint64(u)}, nil
+		}
+		return &object.Integer{Value: int64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: rv.Float()}, nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, rv.Len())
+		for i := range elements {
+			elem, err := ToObject(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = elem
+		}
+		return checked(evaluator.NewArray(elements))
+	case reflect.Map:
+		keys := make([]object.Object, 0, rv.Len())
+		values := make([]object.Object, 0, rv.Len())
+		// a Go map has no order, so its keys are sorted to give the hashmap one
+		mapKeys := rv.MapKeys()
+		sort.Slice(mapKeys, func(i, j int) bool {
+			return fmt.Sprint(mapKeys[i].Interface()) < fmt.Sprint(mapKeys[j].Interface())
+		})
+		for _, k := range mapKeys {
+			key, err := ToObject(k.Interface())
+			if err != nil {
+				return nil, err
+			}
+			value, err := ToObject(rv.MapIndex(k).Interface())
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		return checked(evaluator.NewHash(keys, values))
+	}
+	return nil, fmt.Errorf("can't convert %T to an Azula value", v)
+}
+
+// checked turns an error object from building an array or hashmap into a Go error
+func checked(obj object.Object) (object.Object, error) {
+	if err, ok := obj.(*object.Error); ok {
+		return nil, fmt.Errorf("%s", err.Message)
+	}
+	return obj, nil
+}
+
+// FromObject converts an object to a Go value. Integers become int64s, floats
+// float64s, bigints *big.Ints, arrays []interface{} and hashmaps map[interface{}]interface{}, with null
+// becoming nil. Anything without a Go equivalent, like a function, is given as it is.
+func FromObject(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.BigInt:
+		return new(big.Int).Set(obj.Value)
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Null:
+		return nil
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, elem := range obj.Elements {
+			elements[i] = FromObject(elem)
+		}
+		return elements
+	case *object.Hash:
+		pairs := make(map[interface{}]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			pairs[FromObject(pair.Key)] = FromObject(pair.Value)
+		}
+		return pairs
+	}
+	return obj
+}
+
+// typeOf gives the static type of a value the host gives its programs, which is
+// unknown for anything but the simple types and arrays of them
+func typeOf(obj object.Object) *typecheck.Type {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return typecheck.INT
+	case *object.Float:
+		return typecheck.FLOAT
+	case *object.BigInt:
+		return typecheck.BIGINT
+	case *object.Boolean:
+		return typecheck.BOOL
+	case *object.String:
+		return typecheck.STRING
+	case *object.Array:
+		if len(obj.Elements) == 0 {
+			return typecheck.ArrayOf(typecheck.UNKNOWN)
+		}
+		return typecheck.ArrayOf(typeOf(obj.Elements[0]))
+	}
+	return typecheck.UNKNOWN
+}
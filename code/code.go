@@ -0,0 +1,288 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+	OpGreaterEqual
+	OpLessEqual
+	OpMod
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+	OpMinus
+	OpBang
+	OpBitNot
+
+	OpJump
+	OpJumpNotTruthy
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetFree
+	OpSetFree
+	OpGetLocalCell
+	OpGetFreeCell
+	OpCurrentClosure
+	OpGetField
+	OpSetField
+	OpDefineField
+	OpGetMethod
+	OpGetProperty
+	OpSetProperty
+	OpReceiver
+	OpThis
+	OpGetSuper
+	OpSuper
+	OpSetSuper
+
+	OpArray
+	OpHash
+	OpInterpolate
+	OpIndex
+	OpSlice
+	OpSetIndex
+
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpClosure
+	OpClass
+	OpImport
+
+	OpCheckLet
+	OpCheckReassign
+	OpCheckIterable
+	OpIterNext
+
+	OpTry
+	OpEndTry
+	OpThrow
+	OpCatch
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpNull:     {"OpNull", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpBitAnd:       {"OpBitAnd", []int{}},
+	OpBitOr:        {"OpBitOr", []int{}},
+	OpBitXor:       {"OpBitXor", []int{}},
+	OpShiftLeft:    {"OpShiftLeft", []int{}},
+	OpShiftRight:   {"OpShiftRight", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+	OpBitNot:       {"OpBitNot", []int{}},
+
+	// operands are the absolute position to jump to
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpGetLocal:  {"OpGetLocal", []int{1}},
+	OpSetLocal:  {"OpSetLocal", []int{1}},
+	OpGetFree:   {"OpGetFree", []int{1}},
+	OpSetFree:   {"OpSetFree", []int{1}},
+	// cells are pushed for a closure to capture, so it shares the variable rather than copying it
+	OpGetLocalCell:   {"OpGetLocalCell", []int{1}},
+	OpGetFreeCell:    {"OpGetFreeCell", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+	// field operands are the constant holding the field's name
+	OpGetField:    {"OpGetField", []int{2}},
+	OpSetField:    {"OpSetField", []int{2}},
+	OpDefineField: {"OpDefineField", []int{2}},
+	OpGetMethod:   {"OpGetMethod", []int{2}},
+	OpGetProperty: {"OpGetProperty", []int{2}},
+	OpSetProperty: {"OpSetProperty", []int{2}},
+	OpReceiver:    {"OpReceiver", []int{}},
+	OpThis:        {"OpThis", []int{}},
+	OpGetSuper:    {"OpGetSuper", []int{}},
+	// calls the parent class's constructor on a new layer of the instance
+	OpSuper:    {"OpSuper", []int{1}},
+	OpSetSuper: {"OpSetSuper", []int{}},
+
+	OpArray: {"OpArray", []int{2}},
+	OpHash:  {"OpHash", []int{2}},
+	// how many parts of an interpolated string to join
+	OpInterpolate: {"OpInterpolate", []int{2}},
+	OpIndex:       {"OpIndex", []int{}},
+	// slices the array or string below the start and end, either of which can be null
+	OpSlice: {"OpSlice", []int{}},
+	// sets the element of the array or hashmap below the index to the value below that
+	OpSetIndex: {"OpSetIndex", []int{}},
+
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+	// the constant holding the function, and how many free variables it captures
+	OpClosure: {"OpClosure", []int{2, 1}},
+	// the node the class was defined by
+	OpClass: {"OpClass", []int{2}},
+	// the constant holding the module
+	OpImport: {"OpImport", []int{2}},
+
+	// the node of the statement whose types are being checked
+	OpCheckLet:      {"OpCheckLet", []int{2}},
+	OpCheckReassign: {"OpCheckReassign", []int{}},
+	OpCheckIterable: {"OpCheckIterable", []int{}},
+	// the position to jump to once the iterator is exhausted
+	OpIterNext: {"OpIterNext", []int{2}},
+
+	// the position of the code handling an error raised before the matching OpEndTry
+	OpTry:    {"OpTry", []int{2}},
+	OpEndTry: {"OpEndTry", []int{}},
+	OpThrow:  {"OpThrow", []int{}},
+	// the node of the catch clause the error on the stack is matched against
+	OpCatch: {"OpCatch", []int{2}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Make encodes an instruction from its opcode and operands
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction, giving them along with how many bytes were read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
@@ -0,0 +1,1214 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/code"
+	"github.com/OisinA/Azula/evaluator"
+	"github.com/OisinA/Azula/module"
+	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/token"
+)
+
+// Error is a compile error along with the position in the source it occurred at
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
+// Bytecode is a compiled program. Nodes holds the AST nodes instructions refer
+// to for the checks done while the program runs.
+type Bytecode struct {
+	Main      *object.CompiledFunction
+	Constants []object.Object
+	Nodes     []ast.Node
+}
+
+// loop tracks the jumps out of a loop that need patching once its end is known
+type loop struct {
+	result    Symbol
+	breaks    []int
+	continues []int
+	// how many trys were open when the loop started
+	tries int
+}
+
+// try is a try statement whose body or catch clauses are being compiled. Leaving one
+// early has to close its error handler, if it has one open, and run its finally block.
+type try struct {
+	handler bool
+	finally *ast.BlockStatement
+}
+
+type CompilationScope struct {
+	name         string
+	instructions code.Instructions
+	positions    map[int]token.Position
+	loops        []*loop
+	tries        []*try
+}
+
+type Compiler struct {
+	constants []object.Object
+	nodes     []ast.Node
+
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	pos    token.Position
+	hidden int
+	// modules holds the constant for each module compiled so far, by file
+	modules map[string]int
+	loading module.Stack
+	// classFields holds the names of the fields of each class compiled so far
+	classFields map[string][]string
+	// tooLarge is the first operand found too large for its instruction, if any
+	tooLarge error
+}
+
+func New() *Compiler {
+	mainScope := CompilationScope{
+		instructions: code.Instructions{},
+		positions:    make(map[int]token.Position),
+	}
+
+	return &Compiler{
+		constants:   []object.Object{},
+		nodes:       []ast.Node{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []CompilationScope{mainScope},
+		scopeIndex:  0,
+		modules:     make(map[string]int),
+		classFields: make(map[string][]string),
+	}
+}
+
+// NewWithState gives a compiler that carries on from the symbols and constants of an earlier one
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	compiler := New()
+	compiler.symbolTable = s
+	compiler.constants = constants
+	return compiler
+}
+
+// CompileProgram compiles a whole program into the main function of the bytecode
+func (c *Compiler) CompileProgram(program *ast.Program) error {
+	if err := c.compileBlockValue(program.Statements); err != nil {
+		return err
+	}
+	return c.tooLarge
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	pos := c.pos
+	if p := node.Pos(); p.IsValid() {
+		c.pos = p
+	}
+	defer func() { c.pos = pos }()
+
+	switch node := node.(type) {
+	case *ast.Program:
+		c.hoist(node.Statements)
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+
+	case *ast.BlockStatement:
+		c.hoist(node.Statements)
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.LetStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpCheckLet, c.addNode(node))
+		symbol := c.symbolTable.Define(node.Name.Value)
+		return c.defineSymbol(symbol)
+
+	case *ast.ReassignStatement:
+		symbol, ok := c.symbolTable.Resolve(node.Name.Value)
+		if !ok {
+			return c.errorf("can't reassign value to non-existent variable '%s'", node.Name.Value)
+		}
+		c.loadSymbol(symbol)
+		if node.Operator != "" {
+			c.loadSymbol(symbol)
+		}
+		if err := c.compileAssignedValue(node.Operator, node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpCheckReassign)
+		return c.storeSymbol(symbol)
+
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if len(c.scopes[c.scopeIndex].tries) > 0 {
+			value := c.hiddenSymbol("return")
+			c.storeSymbol(value)
+			if err := c.leaveTries(0); err != nil {
+				return err
+			}
+			c.loadSymbol(value)
+		}
+		c.emit(code.OpReturnValue)
+
+	case *ast.TryStatement:
+		return c.compileTry(node)
+
+	case *ast.ThrowStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+
+	case *ast.ImportStatement:
+		return c.compileImport(node)
+
+	case *ast.BreakStatement:
+		return c.compileLoopExit(node.TokenLiteral(), true)
+
+	case *ast.ContinueStatement:
+		return c.compileLoopExit(node.TokenLiteral(), false)
+
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			if err := c.Compile(part); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpInterpolate, len(node.Parts))
+
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.HashLiteral:
+		for i, key := range node.Keys {
+			if err := c.Compile(key); err != nil {
+				return err
+			}
+			if err := c.Compile(node.Values[i]); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Keys)*2)
+
+	case *ast.Identifier:
+		return c.compileIdentifier(node.Value)
+
+	case *ast.TypedIdentifier:
+		return c.compileIdentifier(node.Value)
+
+	case *ast.PrefixExpression:
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		case "~":
+			c.emit(code.OpBitNot)
+		default:
+			return c.errorf("unknown operator %s", node.Operator)
+		}
+
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogical(node)
+		}
+		op, ok := infixOps[node.Operator]
+		if !ok {
+			return c.errorf("unknown operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+
+	case *ast.SliceExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		for _, bound := range []ast.Expression{node.Start, node.End} {
+			if bound == nil {
+				c.emit(code.OpNull)
+				continue
+			}
+			if err := c.Compile(bound); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpSlice)
+
+	case *ast.IfExpression:
+		return c.compileIf(node)
+
+	case *ast.ForLiteral:
+		return c.compileFor(node)
+
+	case *ast.WhileLiteral:
+		return c.compileWhile(node)
+
+	case *ast.FunctionLiteral:
+		return c.compileFunction(node)
+
+	case *ast.ClassLiteral:
+		return c.compileClass(node)
+
+	case *ast.InterfaceLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Interface{Name: node.Name, Methods: node.Methods}))
+		symbol := c.symbolTable.Define(node.Name.Value)
+		if err := c.defineSymbol(symbol); err != nil {
+			return err
+		}
+		c.loadSymbol(symbol)
+
+	case *ast.PropertyExpression:
+		if err := c.Compile(node.Object); err != nil {
+			return err
+		}
+		c.emit(code.OpGetProperty, c.addConstant(&object.String{Value: node.Property.Value}))
+
+	case *ast.PropertyAssignment:
+		return c.compilePropertyAssignment(node)
+
+	case *ast.IndexAssignment:
+		return c.compileIndexAssignment(node)
+
+	case *ast.ThisExpression:
+		if !c.symbolTable.inClass() {
+			return c.errorf("this outside of a class")
+		}
+		c.emit(code.OpThis)
+
+	case *ast.SuperExpression:
+		if !c.symbolTable.inClass() {
+			return c.errorf("super outside of a subclass")
+		}
+		c.emit(code.OpGetSuper)
+
+	case *ast.CallExpression:
+		if node.Outer != nil {
+			if err := c.Compile(node.Outer); err != nil {
+				return err
+			}
+			c.emit(code.OpGetMethod, c.addConstant(&object.String{Value: node.Function.TokenLiteral()}))
+		} else if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+
+	default:
+		return c.errorf("%T is not supported by the compiler", node)
+	}
+
+	return nil
+}
+
+var infixOps = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+	">=": code.OpGreaterEqual,
+	"<=": code.OpLessEqual,
+	"%":  code.OpMod,
+	"&":  code.OpBitAnd,
+	"|":  code.OpBitOr,
+	"^":  code.OpBitXor,
+	"<<": code.OpShiftLeft,
+	">>": code.OpShiftRight,
+}
+
+// compileAssignedValue compiles the value an assignment sets. For a compound assignment
+// the old value has to be on the stack already, to apply the operator to.
+func (c *Compiler) compileAssignedValue(operator string, value ast.Expression) error {
+	if err := c.Compile(value); err != nil {
+		return err
+	}
+	if operator != "" {
+		op, ok := infixOps[operator]
+		if !ok {
+			return c.errorf("unknown operator %s", operator)
+		}
+		c.emit(op)
+	}
+	return nil
+}
+
+// compilePropertyAssignment compiles setting a field. The object of a compound
+// assignment is kept in a hidden variable, so that it is only evaluated once.
+func (c *Compiler) compilePropertyAssignment(node *ast.PropertyAssignment) error {
+	name := c.addConstant(&object.String{Value: node.Property.Property.Value})
+	if node.Operator == "" {
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Property.Object); err != nil {
+			return err
+		}
+		c.emit(code.OpSetProperty, name)
+		return nil
+	}
+
+	if err := c.Compile(node.Property.Object); err != nil {
+		return err
+	}
+	target := c.hiddenSymbol("target")
+	c.storeSymbol(target)
+	c.loadSymbol(target)
+	c.emit(code.OpGetProperty, name)
+	if err := c.compileAssignedValue(node.Operator, node.Value); err != nil {
+		return err
+	}
+	c.loadSymbol(target)
+	c.emit(code.OpSetProperty, name)
+	return nil
+}
+
+// compileIndexAssignment compiles setting an element, in the same way as a field
+func (c *Compiler) compileIndexAssignment(node *ast.IndexAssignment) error {
+	if node.Operator == "" {
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpSetIndex)
+		return nil
+	}
+
+	if err := c.Compile(node.Index.Left); err != nil {
+		return err
+	}
+	target := c.hiddenSymbol("target")
+	c.storeSymbol(target)
+	if err := c.Compile(node.Index.Index); err != nil {
+		return err
+	}
+	index := c.hiddenSymbol("index")
+	c.storeSymbol(index)
+
+	c.loadSymbol(target)
+	c.loadSymbol(index)
+	c.emit(code.OpIndex)
+	if err := c.compileAssignedValue(node.Operator, node.Value); err != nil {
+		return err
+	}
+	c.loadSymbol(target)
+	c.loadSymbol(index)
+	c.emit(code.OpSetIndex)
+	return nil
+}
+
+// compileLogical compiles && or || so that the right side only runs if the left
+// doesn't decide the result by itself, which is then false or true respectively
+func (c *Compiler) compileLogical(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if node.Operator == "&&" {
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+		c.emit(code.OpFalse)
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+		return nil
+	}
+
+	c.emit(code.OpTrue)
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileBlockValue compiles a list of statements so that they leave the value of
+// the last one on the stack, the way the evaluator gives the value of a block
+func (c *Compiler) compileBlockValue(stmts []ast.Statement) error {
+	c.hoist(stmts)
+
+	for i, s := range stmts {
+		if es, ok := s.(*ast.ExpressionStatement); ok && i == len(stmts)-1 {
+			pos := c.pos
+			c.pos = es.Pos()
+			err := c.Compile(es.Expression)
+			c.pos = pos
+			return err
+		}
+		if err := c.Compile(s); err != nil {
+			return err
+		}
+	}
+
+	c.emit(code.OpNull)
+	return nil
+}
+
+// hoist defines the names of the functions and classes in a block before it is
+// compiled, so they can be referred to before their definition
+func (c *Compiler) hoist(stmts []ast.Statement) {
+	for _, s := range stmts {
+		es, ok := s.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		switch exp := es.Expression.(type) {
+		case *ast.FunctionLiteral:
+			if exp.Name != nil {
+				c.symbolTable.Define(exp.Name.Value)
+			}
+		case *ast.ClassLiteral:
+			c.symbolTable.Define(exp.Name.Value)
+		}
+	}
+}
+
+func (c *Compiler) compileIdentifier(name string) error {
+	if symbol, ok := c.symbolTable.Resolve(name); ok {
+		c.loadSymbol(symbol)
+		return nil
+	}
+	if builtin, ok := evaluator.LookupBuiltin(name); ok {
+		c.emit(code.OpConstant, c.addConstant(builtin))
+		return nil
+	}
+	return c.errorf("identifier not found: %s", name)
+}
+
+func (c *Compiler) compileIf(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compileBlockValue(node.Consequence.Statements); err != nil {
+		return err
+	}
+
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBlockValue(node.Alternative.Statements); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// hiddenSymbol defines a variable the program can't name, for the compiler's own bookkeeping
+func (c *Compiler) hiddenSymbol(kind string) Symbol {
+	c.hidden++
+	return c.symbolTable.Define(fmt.Sprintf("$%s%d", kind, c.hidden))
+}
+
+func (c *Compiler) compileFor(node *ast.ForLiteral) error {
+	if err := c.Compile(node.Iterator); err != nil {
+		return err
+	}
+	c.emit(code.OpCheckIterable)
+	iter := c.hiddenSymbol("iter")
+	c.storeSymbol(iter)
+
+	index := c.hiddenSymbol("index")
+	c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: 0}))
+	c.storeSymbol(index)
+
+	l := &loop{result: c.hiddenSymbol("result")}
+	c.emit(code.OpNull)
+	c.storeSymbol(l.result)
+
+	start := len(c.currentInstructions())
+	c.loadSymbol(iter)
+	c.loadSymbol(index)
+	next := c.emit(code.OpIterNext, 9999)
+	if err := c.defineSymbol(c.symbolTable.Define(node.Parameter.Value)); err != nil {
+		return err
+	}
+
+	if err := c.compileLoopBody(l, node.Body); err != nil {
+		return err
+	}
+
+	cont := len(c.currentInstructions())
+	c.loadSymbol(index)
+	c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: 1}))
+	c.emit(code.OpAdd)
+	c.storeSymbol(index)
+	c.emit(code.OpJump, start)
+
+	end := len(c.currentInstructions())
+	c.changeOperand(next, end)
+	c.patchLoop(l, cont, end)
+	c.loadSymbol(l.result)
+	return nil
+}
+
+func (c *Compiler) compileWhile(node *ast.WhileLiteral) error {
+	l := &loop{result: c.hiddenSymbol("result")}
+	c.emit(code.OpNull)
+	c.storeSymbol(l.result)
+
+	start := len(c.currentInstructions())
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	exit := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compileLoopBody(l, node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpJump, start)
+
+	end := len(c.currentInstructions())
+	c.changeOperand(exit, end)
+	c.patchLoop(l, start, end)
+	c.loadSymbol(l.result)
+	return nil
+}
+
+func (c *Compiler) compileLoopBody(l *loop, body *ast.BlockStatement) error {
+	scope := &c.scopes[c.scopeIndex]
+	l.tries = len(scope.tries)
+	scope.loops = append(scope.loops, l)
+	if err := c.compileBlockValue(body.Statements); err != nil {
+		return err
+	}
+	c.storeSymbol(l.result)
+	scope = &c.scopes[c.scopeIndex]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+	return nil
+}
+
+func (c *Compiler) patchLoop(l *loop, cont int, end int) {
+	for _, pos := range l.continues {
+		c.changeOperand(pos, cont)
+	}
+	for _, pos := range l.breaks {
+		c.changeOperand(pos, end)
+	}
+}
+
+// compileLoopExit compiles a break or continue, which both leave the loop's result as null
+func (c *Compiler) compileLoopExit(kind string, isBreak bool) error {
+	scope := c.scopes[c.scopeIndex]
+	if len(scope.loops) == 0 {
+		if scope.name != "" {
+			return c.errorf("%s outside of a loop in function %s", kind, scope.name)
+		}
+		return c.errorf("%s outside of a loop", kind)
+	}
+	l := scope.loops[len(scope.loops)-1]
+	if err := c.leaveTries(l.tries); err != nil {
+		return err
+	}
+
+	c.emit(code.OpNull)
+	c.storeSymbol(l.result)
+	pos := c.emit(code.OpJump, 9999)
+	if isBreak {
+		l.breaks = append(l.breaks, pos)
+	} else {
+		l.continues = append(l.continues, pos)
+	}
+	return nil
+}
+
+// compileTry lays a try statement out as its body, then the code its error handler
+// jumps to, which leaves the error on the stack for the catch clauses to match. The
+// finally block is copied onto the end of each way out of the statement, with a
+// second handler around the catch clauses so it also runs when they raise an error.
+func (c *Compiler) compileTry(node *ast.TryStatement) error {
+	handler := c.emit(code.OpTry, 9999)
+	if err := c.compileTryBlock(&try{handler: true, finally: node.Finally}, node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpEndTry)
+	if err := c.compileFinally(node.Finally); err != nil {
+		return err
+	}
+	ends := []int{c.emit(code.OpJump, 9999)}
+
+	c.changeOperand(handler, len(c.currentInstructions()))
+	var finallyHandler int
+	if node.Finally != nil {
+		finallyHandler = c.emit(code.OpTry, 9999)
+	}
+	catches := []int{}
+	for _, clause := range node.Catches {
+		next := -1
+		if clause.Kind != nil {
+			c.emit(code.OpCatch, c.addNode(clause))
+			next = c.emit(code.OpJumpNotTruthy, 9999)
+		}
+		// the error is only named inside its clause
+		c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+		if err := c.defineSymbol(c.symbolTable.Define(clause.Name.Value)); err != nil {
+			return err
+		}
+		if err := c.compileTryBlock(&try{handler: node.Finally != nil, finally: node.Finally}, clause.Body); err != nil {
+			return err
+		}
+		c.symbolTable = c.symbolTable.Outer
+		catches = append(catches, c.emit(code.OpJump, 9999))
+		if next >= 0 {
+			c.changeOperand(next, len(c.currentInstructions()))
+		}
+	}
+	// no clause caught the error, so it carries on to the next handler
+	c.emit(code.OpThrow)
+
+	for _, pos := range catches {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+	if node.Finally != nil {
+		c.emit(code.OpEndTry)
+		if err := c.compileFinally(node.Finally); err != nil {
+			return err
+		}
+		ends = append(ends, c.emit(code.OpJump, 9999))
+
+		c.changeOperand(finallyHandler, len(c.currentInstructions()))
+		raised := c.hiddenSymbol("error")
+		c.storeSymbol(raised)
+		if err := c.compileFinally(node.Finally); err != nil {
+			return err
+		}
+		c.loadSymbol(raised)
+		c.emit(code.OpThrow)
+	}
+
+	for _, pos := range ends {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+	return nil
+}
+
+func (c *Compiler) compileTryBlock(t *try, block *ast.BlockStatement) error {
+	scope := &c.scopes[c.scopeIndex]
+	scope.tries = append(scope.tries, t)
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+	scope = &c.scopes[c.scopeIndex]
+	scope.tries = scope.tries[:len(scope.tries)-1]
+	return nil
+}
+
+func (c *Compiler) compileFinally(block *ast.BlockStatement) error {
+	if block == nil {
+		return nil
+	}
+	return c.Compile(block)
+}
+
+// leaveTries closes the trys a return, break or continue jumps out of, innermost
+// first, down to the given depth
+func (c *Compiler) leaveTries(depth int) error {
+	scope := &c.scopes[c.scopeIndex]
+	tries := scope.tries
+	for i := len(tries) - 1; i >= depth; i-- {
+		if tries[i].handler {
+			c.emit(code.OpEndTry)
+		}
+		// a finally block leaving early only has the trys outside it left to close
+		scope.tries = tries[:i]
+		err := c.compileFinally(tries[i].finally)
+		scope = &c.scopes[c.scopeIndex]
+		scope.tries = tries
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileFunction compiles a function into a closure, binding it to the function's
+// name unless it is anonymous. Either way the closure is left as the expression's value.
+func (c *Compiler) compileFunction(node *ast.FunctionLiteral) error {
+	c.enterScope(node.FunctionName(), NewEnclosedSymbolTable(c.symbolTable))
+	if node.Name != nil {
+		c.symbolTable.DefineFunctionName(node.Name.Value)
+	}
+
+	for _, p := range node.Parameters {
+		c.symbolTable.Define(p.Value)
+	}
+
+	if err := c.compileBlockValue(node.Body.Statements); err != nil {
+		return err
+	}
+	c.emit(code.OpReturnValue)
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	instructions, positions := c.leaveScope()
+
+	for _, s := range freeSymbols {
+		c.captureSymbol(s)
+	}
+
+	fn := &object.CompiledFunction{
+		Name:          node.FunctionName(),
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Parameters:    node.Parameters,
+		ReturnType:    node.ReturnType,
+		Positions:     positions,
+	}
+	c.emit(code.OpClosure, c.addConstant(fn), len(freeSymbols))
+	if node.Name == nil {
+		return nil
+	}
+
+	symbol := c.symbolTable.Define(node.Name.Value)
+	if err := c.defineSymbol(symbol); err != nil {
+		return err
+	}
+	c.loadSymbol(symbol)
+	return nil
+}
+
+// compileClass compiles the body of a class into a constructor, which is run with
+// the new instance as its receiver. Everything the body defines becomes a field.
+func (c *Compiler) compileClass(node *ast.ClassLiteral) error {
+	c.enterScope(node.Name.Value, NewClassSymbolTable(c.symbolTable))
+
+	if node.Parent != nil {
+		// inherited fields are found on the parent's layer of the instance
+		for _, name := range c.classFields[node.Parent.Value] {
+			c.symbolTable.Define(name)
+		}
+	}
+	for _, p := range node.Parameters {
+		c.symbolTable.Define(p.Value)
+	}
+
+	if node.Parent != nil {
+		if err := c.compileSuperConstructor(node); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpReceiver)
+	c.emit(code.OpReturnValue)
+
+	c.classFields[node.Name.Value] = c.symbolTable.Fields()
+	freeSymbols := c.symbolTable.FreeSymbols
+	instructions, positions := c.leaveScope()
+
+	for _, s := range freeSymbols {
+		c.captureSymbol(s)
+	}
+
+	fn := &object.CompiledFunction{
+		Name:          node.Name.Value,
+		Instructions:  instructions,
+		NumParameters: len(node.Parameters),
+		Parameters:    node.Parameters,
+		Positions:     positions,
+	}
+	c.emit(code.OpClosure, c.addConstant(fn), len(freeSymbols))
+	if node.Parent != nil {
+		if err := c.Compile(node.Parent); err != nil {
+			return err
+		}
+	}
+	for _, iface := range node.Interfaces {
+		if err := c.Compile(iface); err != nil {
+			return err
+		}
+	}
+	c.emit(code.OpClass, c.addNode(node))
+
+	symbol := c.symbolTable.Define(node.Name.Value)
+	if err := c.defineSymbol(symbol); err != nil {
+		return err
+	}
+	c.loadSymbol(symbol)
+	return nil
+}
+
+// compileSuperConstructor compiles the call to the parent's constructor, which builds
+// the parent's layer of the instance before the class's own body runs
+func (c *Compiler) compileSuperConstructor(node *ast.ClassLiteral) error {
+	// the parent's constructor returns in place of the callee, as for any call
+	c.emit(code.OpNull)
+
+	numArgs := len(node.Parameters)
+	if node.ParentArguments == nil {
+		for _, p := range node.Parameters {
+			c.emit(code.OpGetField, c.addConstant(&object.String{Value: p.Value}))
+		}
+	} else {
+		for _, a := range node.ParentArguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		numArgs = len(node.ParentArguments)
+	}
+	// the class's name is where the call appears in a traceback
+	pos := c.pos
+	c.pos = node.Name.Pos()
+	c.emit(code.OpSuper, numArgs)
+	c.pos = pos
+
+	c.emit(code.OpSetSuper)
+	return nil
+}
+
+// compileImport binds the module an import refers to, compiling it the first time
+// it is imported
+func (c *Compiler) compileImport(node *ast.ImportStatement) error {
+	str, ok := node.Value.(*ast.StringLiteral)
+	if !ok {
+		return c.errorf("invalid import path")
+	}
+	file, ok := module.Resolve(str.Value, node.Pos().Filename)
+	if !ok {
+		return c.errorf("couldn't import file '%s'", str.Value)
+	}
+	name, nameErr := evaluator.ImportName(node, file)
+	if nameErr != nil {
+		return c.errorf("%s", nameErr.Message)
+	}
+
+	index, ok := c.modules[file]
+	if !ok {
+		var err error
+		if index, err = c.compileModule(node, file); err != nil {
+			return err
+		}
+	}
+	c.emit(code.OpImport, index)
+
+	symbol := c.symbolTable.Define(name)
+	return c.defineSymbol(symbol)
+}
+
+// compileModule compiles the top level of a module into a function, which the vm runs
+// the first time the module is imported. It gives the constant holding the module.
+func (c *Compiler) compileModule(node *ast.ImportStatement, file string) (int, error) {
+	if cycle, ok := c.loading.Cycle(file, node.Pos().Filename); ok {
+		return 0, c.errorf("import cycle: %s", cycle)
+	}
+	program, err := module.Parse(file)
+	if err != nil {
+		return 0, c.errorf("something went wrong while importing '%s': %s", node.Value, err)
+	}
+
+	mod := &object.Module{Name: module.Name(file), File: file}
+	index := c.addConstant(mod)
+
+	outer := c.symbolTable
+	table := NewModuleSymbolTable(outer)
+	loading := c.loading
+	c.loading = loading.Enter(file, node.Pos().Filename)
+	c.enterScope(mod.Name, table)
+	if err := c.Compile(program); err != nil {
+		return 0, err
+	}
+	// the module has run by the time its top level ends, so importing it again gives it
+	c.emit(code.OpImport, index)
+	c.emit(code.OpReturnValue)
+	instructions, positions := c.leaveScope()
+	c.symbolTable = outer
+	c.loading = loading
+
+	mod.Slots = table.Globals()
+	mod.Init = &object.CompiledFunction{
+		Name:         mod.Name,
+		Instructions: instructions,
+		Positions:    positions,
+	}
+	c.modules[file] = index
+	return index, nil
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	case FieldScope:
+		c.emit(code.OpGetField, c.addConstant(&object.String{Value: s.Name}))
+	}
+}
+
+// defineSymbol stores the value for a new definition. Unlike an assignment, a field
+// definition never changes a field of the same name inherited from a parent.
+func (c *Compiler) defineSymbol(s Symbol) error {
+	if s.Scope == FieldScope {
+		c.emit(code.OpDefineField, c.addConstant(&object.String{Value: s.Name}))
+		return nil
+	}
+	return c.storeSymbol(s)
+}
+
+func (c *Compiler) storeSymbol(s Symbol) error {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpSetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	case FieldScope:
+		c.emit(code.OpSetField, c.addConstant(&object.String{Value: s.Name}))
+	default:
+		return c.errorf("can't assign to function %s from inside itself", s.Name)
+	}
+	return nil
+}
+
+// captureSymbol pushes what a closure keeps for a variable it uses from an enclosing
+// function. Locals and free variables are shared through a cell, so an assignment by
+// either function is seen by the other.
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpGetLocalCell, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFreeCell, s.Index)
+	default:
+		c.loadSymbol(s)
+	}
+}
+
+func (c *Compiler) errorf(format string, a ...interface{}) error {
+	return &Error{Pos: c.pos, Message: fmt.Sprintf(format, a...)}
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) addNode(node ast.Node) int {
+	c.nodes = append(c.nodes, node)
+	return len(c.nodes) - 1
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+	c.scopes[c.scopeIndex].positions[pos] = c.pos
+	return pos
+}
+
+// operandLimits names what the operands of an instruction count, for the error given when
+// a program has more of them than the operand can hold
+var operandLimits = map[code.Opcode][]string{
+	code.OpConstant:      {"constants"},
+	code.OpClosure:       {"constants", "variables captured by one function"},
+	code.OpGetGlobal:     {"global variables"},
+	code.OpSetGlobal:     {"global variables"},
+	code.OpGetLocal:      {"local variables in one function"},
+	code.OpSetLocal:      {"local variables in one function"},
+	code.OpGetLocalCell:  {"local variables in one function"},
+	code.OpGetFree:       {"variables captured by one function"},
+	code.OpSetFree:       {"variables captured by one function"},
+	code.OpGetFreeCell:   {"variables captured by one function"},
+	code.OpJump:          {"instructions in one function"},
+	code.OpJumpNotTruthy: {"instructions in one function"},
+	code.OpIterNext:      {"instructions in one function"},
+	code.OpTry:           {"instructions in one function"},
+	code.OpArray:         {"elements in one array"},
+	code.OpHash:          {"pairs in one hashmap"},
+	code.OpInterpolate:   {"parts in one string"},
+	code.OpCall:          {"arguments in one call"},
+	code.OpSuper:         {"arguments in one call"},
+	code.OpGetField:      {"constants"},
+	code.OpSetField:      {"constants"},
+	code.OpDefineField:   {"constants"},
+	code.OpGetMethod:     {"constants"},
+	code.OpGetProperty:   {"constants"},
+	code.OpSetProperty:   {"constants"},
+	code.OpImport:        {"constants"},
+	// these refer to the node of a declaration or catch clause
+	code.OpClass:    {"declarations"},
+	code.OpCheckLet: {"declarations"},
+	code.OpCatch:    {"declarations"},
+}
+
+// checkOperands keeps an error for the first operand that doesn't fit in the bytes its
+// instruction has for it, which the program is given once it has been compiled
+func (c *Compiler) checkOperands(op code.Opcode, operands []int) {
+	def, err := code.Lookup(byte(op))
+	if err != nil || c.tooLarge != nil {
+		return
+	}
+	for i, operand := range operands {
+		limit := 1 << (8 * uint(def.OperandWidths[i]))
+		if operand >= 0 && operand < limit {
+			continue
+		}
+		what := "operands to " + def.Name
+		if names, ok := operandLimits[op]; ok {
+			what = names[i]
+		}
+		c.tooLarge = c.errorf("too many %s (the limit is %d)", what, limit)
+		return
+	}
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	posNewInstruction := len(c.currentInstructions())
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), ins...)
+	return posNewInstruction
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	c.checkOperands(op, []int{operand})
+	newInstruction := code.Make(op, operand)
+
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+func (c *Compiler) enterScope(name string, table *SymbolTable) {
+	scope := CompilationScope{
+		name:         name,
+		instructions: code.Instructions{},
+		positions:    make(map[int]token.Position),
+	}
+	c.scopes = append(c.scopes, scope)
+	c.scopeIndex++
+
+	c.symbolTable = table
+}
+
+func (c *Compiler) leaveScope() (code.Instructions, map[int]token.Position) {
+	scope := c.scopes[c.scopeIndex]
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return scope.instructions, scope.positions
+}
+
+// SymbolTable gives the compiler's global symbols, to carry on compiling with later
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	scope := c.scopes[0]
+	instructions := append(code.Instructions{}, scope.instructions...)
+	instructions = append(instructions, code.Make(code.OpReturnValue)...)
+
+	return &Bytecode{
+		Main: &object.CompiledFunction{
+			Name:         "main",
+			Instructions: instructions,
+			NumLocals:    0,
+			Positions:    scope.positions,
+		},
+		Constants: c.constants,
+		Nodes:     c.nodes,
+	}
+}
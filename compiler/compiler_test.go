@@ -0,0 +1,248 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OisinA/Azula/code"
+	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/parser"
+)
+
+func compile(t *testing.T, input string) *Bytecode {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	c := New()
+	if err := c.CompileProgram(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	return c.Bytecode()
+}
+
+func concat(instructions ...[]byte) code.Instructions {
+	out := code.Instructions{}
+	for _, ins := range instructions {
+		out = append(out, ins...)
+	}
+	return out
+}
+
+func TestCompileInstructions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected code.Instructions
+	}{
+		{
+			"1 + 2",
+			concat(
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpReturnValue),
+			),
+		},
+		{
+			"int x = 1; x;",
+			concat(
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpCheckLet, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpReturnValue),
+			),
+		},
+		{
+			"if(true) { 10 }; 3;",
+			concat(
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpReturnValue),
+			),
+		},
+		{
+			"true && false",
+			concat(
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 8),
+				code.Make(code.OpFalse),
+				code.Make(code.OpJump, 9),
+				code.Make(code.OpFalse),
+				code.Make(code.OpReturnValue),
+			),
+		},
+		{
+			"false || true",
+			concat(
+				code.Make(code.OpFalse),
+				code.Make(code.OpJumpNotTruthy, 8),
+				code.Make(code.OpTrue),
+				code.Make(code.OpJump, 9),
+				code.Make(code.OpTrue),
+				code.Make(code.OpReturnValue),
+			),
+		},
+		{
+			"1 % 2 >= ~3",
+			concat(
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMod),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpBitNot),
+				code.Make(code.OpGreaterEqual),
+				code.Make(code.OpReturnValue),
+			),
+		},
+		{
+			"int x = 1;",
+			concat(
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpCheckLet, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpReturnValue),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		bytecode := compile(t, tt.input)
+		if bytecode.Main.Instructions.String() != tt.expected.String() {
+			t.Errorf("wrong instructions for %q.\nwant=\n%s\ngot=\n%s", tt.input, tt.expected, bytecode.Main.Instructions)
+		}
+	}
+}
+
+func TestResolveScopes(t *testing.T) {
+	global := NewSymbolTable()
+	a := global.Define("a")
+
+	class := NewClassSymbolTable(global)
+	field := class.Define("field")
+
+	local := NewEnclosedSymbolTable(class)
+	b := local.Define("b")
+
+	expected := map[string]Symbol{
+		"a":     a,
+		"field": field,
+		"b":     b,
+	}
+	for name, want := range expected {
+		got, ok := local.Resolve(name)
+		if !ok {
+			t.Fatalf("name %s not resolvable", name)
+		}
+		if got != want {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", name, want, got)
+		}
+	}
+
+	if field.Scope != FieldScope {
+		t.Errorf("class definitions should be fields. got=%s", field.Scope)
+	}
+	if len(local.FreeSymbols) != 0 {
+		t.Errorf("globals and fields shouldn't be captured. got=%+v", local.FreeSymbols)
+	}
+}
+
+func TestModuleSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+	a := global.Define("a")
+
+	mod := NewModuleSymbolTable(global)
+	b := mod.Define("b")
+	c := global.Define("c")
+
+	if b.Scope != GlobalScope || b.Index != a.Index+1 || c.Index != b.Index+1 {
+		t.Errorf("module globals should get slots of their own. got a=%+v, b=%+v, c=%+v", a, b, c)
+	}
+	if _, ok := mod.Resolve("a"); ok {
+		t.Errorf("a module shouldn't see the names of the program importing it")
+	}
+	if _, ok := global.Resolve("b"); ok {
+		t.Errorf("a program shouldn't see the names of a module it imports")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"foobar", "identifier not found: foobar"},
+		{"break;", "break outside of a loop"},
+		{"x = 1;", "can't reassign value to non-existent variable 'x'"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		err := New().CompileProgram(p.ParseProgram())
+		compileErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("expected compile error for %q. got=%v", tt.input, err)
+			continue
+		}
+		if compileErr.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, compileErr.Message)
+		}
+	}
+}
+
+func TestOperandLimits(t *testing.T) {
+	// identifiers can't have digits in them, so the locals are named vaa, vab and so on
+	locals := "func f(): int { "
+	for i := 0; i < 257; i++ {
+		locals += fmt.Sprintf("int v%c%c = 0; ", 'a'+i/26, 'a'+i%26)
+	}
+	locals += "return vaa; } f();"
+
+	constants := strings.Repeat("1.5; ", 65537)
+	jump := "int x = 0; if (true) { " + strings.Repeat("x; ", 17000) + "}"
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{locals, "too many local variables in one function (the limit is 256)"},
+		{constants, "too many constants (the limit is 65536)"},
+		{jump, "too many instructions in one function (the limit is 65536)"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors()[0])
+		}
+		err := New().CompileProgram(program)
+		compileErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("expected compile error for %.40q. got=%v", tt.input, err)
+			continue
+		}
+		if compileErr.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, compileErr.Message)
+		}
+	}
+
+	// a program can't define enough globals to reach the limit without first running
+	// out of constants, so the instruction is emitted directly
+	c := New()
+	c.emit(code.OpSetGlobal, 65535)
+	if c.tooLarge != nil {
+		t.Errorf("unexpected error for the last global. got=%v", c.tooLarge)
+	}
+	c.emit(code.OpSetGlobal, 65536)
+	if c.tooLarge == nil || c.tooLarge.(*Error).Message != "too many global variables (the limit is 65536)" {
+		t.Errorf("wrong error for too many globals. got=%v", c.tooLarge)
+	}
+}
@@ -0,0 +1,169 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope   SymbolScope = "GLOBAL"
+	LocalScope    SymbolScope = "LOCAL"
+	FreeScope     SymbolScope = "FREE"
+	FunctionScope SymbolScope = "FUNCTION"
+	// FieldScope symbols live on the instance of the class being built or called
+	FieldScope SymbolScope = "FIELD"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store          map[string]Symbol
+	numDefinitions int
+	// class tables define their names as fields, rather than locals
+	class bool
+	// block tables scope names to part of a function, in slots of the function's own
+	block bool
+	// numGlobals counts the globals defined by the program, which the tables for the
+	// top level of each of its modules share
+	numGlobals *int
+
+	FreeSymbols []Symbol
+}
+
+func NewSymbolTable() *SymbolTable {
+	s := make(map[string]Symbol)
+	free := []Symbol{}
+	return &SymbolTable{store: s, FreeSymbols: free, numGlobals: new(int)}
+}
+
+// NewModuleSymbolTable gives a table for the top level of an imported module. Its
+// names are globals, but in slots of their own that the importing program can't see.
+func NewModuleSymbolTable(importer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.numGlobals = importer.numGlobals
+	return s
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// NewClassSymbolTable gives a table for the body of a class, where every definition is a field
+func NewClassSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewEnclosedSymbolTable(outer)
+	s.class = true
+	return s
+}
+
+// NewBlockSymbolTable gives a table for a block inside outer, whose names can't be
+// seen once the block ends but are stored alongside the ones outer defines
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewEnclosedSymbolTable(outer)
+	s.numGlobals = outer.numGlobals
+	s.block = true
+	return s
+}
+
+// Define adds a symbol to the table, reusing the existing one if the name is already defined here
+func (s *SymbolTable) Define(name string) Symbol {
+	if symbol, ok := s.store[name]; ok && symbol.Scope != FunctionScope {
+		return symbol
+	}
+
+	// a block's names take their slots from the table of the function it's in
+	owner := s
+	for owner.block {
+		owner = owner.Outer
+	}
+
+	symbol := Symbol{Name: name, Index: owner.numDefinitions}
+	switch {
+	case owner.class:
+		symbol.Scope = FieldScope
+	case owner.Outer == nil:
+		symbol.Scope = GlobalScope
+		symbol.Index = *s.numGlobals
+		*s.numGlobals++
+	default:
+		symbol.Scope = LocalScope
+	}
+
+	s.store[name] = symbol
+	if symbol.Scope != FieldScope {
+		owner.numDefinitions++
+	}
+	return symbol
+}
+
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Index: 0, Scope: FunctionScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1}
+	symbol.Scope = FreeScope
+
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	obj, ok := s.store[name]
+	if !ok && s.Outer != nil {
+		obj, ok = s.Outer.Resolve(name)
+		if !ok {
+			return obj, ok
+		}
+
+		// globals and fields are looked up where they live, as is everything outside
+		// a block in the same function, and everything else is captured
+		if s.block || obj.Scope == GlobalScope || obj.Scope == FieldScope {
+			return obj, ok
+		}
+
+		free := s.defineFree(obj)
+		return free, true
+	}
+	return obj, ok
+}
+
+// Globals gives the slot of each global defined in the table
+func (s *SymbolTable) Globals() map[string]int {
+	globals := make(map[string]int)
+	for name, symbol := range s.store {
+		if symbol.Scope == GlobalScope {
+			globals[name] = symbol.Index
+		}
+	}
+	return globals
+}
+
+// Fields gives the names of the fields defined in a class table
+func (s *SymbolTable) Fields() []string {
+	fields := []string{}
+	for name, symbol := range s.store {
+		if symbol.Scope == FieldScope {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// inClass reports whether the table is for a class body, or a function inside one
+func (s *SymbolTable) inClass() bool {
+	for t := s; t != nil; t = t.Outer {
+		if t.class {
+			return true
+		}
+	}
+	return false
+}
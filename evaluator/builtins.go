@@ -1,90 +1,152 @@
 package evaluator
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
+	"math"
+	"math/big"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/OisinA/Azula/object"
 )
 
+// builtins are the language's own, shared by every program so never changed. A host
+// adds its own to a program's environment instead.
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%q, want=1", len(args))
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("len", len(args), 1); err != nil {
+				return err
 			}
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
 			default:
-				return newError("argument to 'len' not supported, got %s", args[0].Type())
+				return newKindError(object.TYPE_ERROR, "argument to 'len' not supported, got %s", args[0].Type())
 			}
 		},
 	},
 	"input": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) > 1 {
-				return newError("wrong number of arguments. got=%q, want <= 1", len(args))
+				return newKindError(object.ARITY_ERROR, "wrong number of arguments to input. got=%d, want=0 or 1", len(args))
 			}
 			if len(args) == 1 {
-				fmt.Print(args[0].Inspect())
+				fmt.Fprint(ctx.Out, args[0].Inspect())
 			}
 
-			reader := bufio.NewReader(os.Stdin)
-			text, err := reader.ReadString('\n')
-			if err != nil {
+			// the last line of the input may not end in a newline
+			text, err := ctx.In.ReadString('\n')
+			if err != nil && (err != io.EOF || text == "") {
 				return newError("error reading in input")
 			}
 			return &object.String{Value: strings.TrimSpace(string(text))}
 		},
 	},
 	"to_int": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%q, want 1", len(args))
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("to_int", len(args), 1); err != nil {
+				return err
+			}
+			switch arg := args[0].(type) {
+			case *object.Float:
+				if math.IsNaN(arg.Value) {
+					return newKindError(object.CONVERSION_ERROR, "couldn't convert NaN to int")
+				}
+				// int64 converts anything outside its range to the smallest int
+				if arg.Value < math.MinInt64 || arg.Value >= math.MaxInt64 {
+					return newKindError(object.OVERFLOW_ERROR, "float %s is too large to convert to int", arg.Inspect())
+				}
+				return &object.Integer{Value: int64(arg.Value)}
+			case *object.BigInt:
+				if !arg.Value.IsInt64() {
+					return newKindError(object.OVERFLOW_ERROR, "bigint %s is too large to convert to int", arg.Value)
+				}
+				return &object.Integer{Value: arg.Value.Int64()}
 			}
 			i, err := strconv.Atoi(args[0].Inspect())
 			if err != nil {
-				return newError("couldn't convert '%s' to int", args[0].Inspect())
+				return newKindError(object.CONVERSION_ERROR, "couldn't convert '%s' to int", args[0].Inspect())
 			}
 			return &object.Integer{Value: int64(i)}
 		},
 	},
+	"to_float": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("to_float", len(args), 1); err != nil {
+				return err
+			}
+			if i, ok := args[0].(*object.Integer); ok {
+				return &object.Float{Value: float64(i.Value)}
+			}
+			f, err := strconv.ParseFloat(args[0].Inspect(), 64)
+			if err != nil {
+				return newKindError(object.CONVERSION_ERROR, "couldn't convert '%s' to float", args[0].Inspect())
+			}
+			return &object.Float{Value: f}
+		},
+	},
+	"to_bigint": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("to_bigint", len(args), 1); err != nil {
+				return err
+			}
+			switch arg := args[0].(type) {
+			case *object.BigInt:
+				return arg
+			case *object.Integer:
+				return &object.BigInt{Value: big.NewInt(arg.Value)}
+			case *object.Float:
+				if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+					break
+				}
+				i, _ := big.NewFloat(arg.Value).Int(nil)
+				return &object.BigInt{Value: i}
+			case *object.String:
+				if i, ok := new(big.Int).SetString(arg.Value, 10); ok {
+					return &object.BigInt{Value: i}
+				}
+			}
+			return newKindError(object.CONVERSION_ERROR, "couldn't convert '%s' to bigint", args[0].Inspect())
+		},
+	},
 	"print": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%q, want 1", len(args))
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("print", len(args), 1); err != nil {
+				return err
 			}
-			fmt.Println(args[0].Inspect())
+			fmt.Fprintln(ctx.Out, args[0].Inspect())
 			return NULL
 		},
 	},
 	"range": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			lower := int64(0)
 			higher := int64(0)
 			if len(args) == 1 {
 				hi, ok := args[0].(*object.Integer)
 				if !ok {
-					return newError("can't get range of non-int " + args[0].Inspect())
+					return newKindError(object.TYPE_ERROR, "can't get range of non-int %s", args[0].Inspect())
 				}
 				higher = hi.Value
 			} else if len(args) == 2 {
 				low, ok := args[0].(*object.Integer)
 				hi, ok := args[1].(*object.Integer)
 				if !ok {
-					return newError("can't get range of a non-int")
+					return newKindError(object.TYPE_ERROR, "can't get range of a non-int")
 				}
 				lower = low.Value
 				higher = hi.Value
 			} else {
-				return newError("wrong number of arguments. got=%q, want 1/2", len(args))
+				return newKindError(object.ARITY_ERROR, "wrong number of arguments to range. got=%d, want=1 or 2", len(args))
 			}
 			array := &object.Array{ElementType: "int", Elements: []object.Object{}}
 			for i := lower; i < higher; i++ {
@@ -94,14 +156,14 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"string_to_list": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%q", len(args))
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("string_to_list", len(args), 1); err != nil {
+				return err
 			}
 
 			s, ok := args[0].(*object.String)
 			if !ok {
-				return newError("cannot convert %v to string", args[0])
+				return newKindError(object.TYPE_ERROR, "cannot convert %v to string", args[0])
 			}
 			array := &object.Array{ElementType: "string", Elements: []object.Object{}}
 			for _, c := range s.Value {
@@ -111,44 +173,509 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"append": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%q", len(args))
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("append", len(args), 2); err != nil {
+				return err
 			}
 			l, ok := args[0].(*object.Array)
 			if !ok {
-				return newError("cannot convert %v to array", args[0])
+				return newKindError(object.TYPE_ERROR, "cannot convert %v to array", args[0])
 			}
 
-			return &object.Array{ElementType: l.ElementType, Elements: append(l.Elements, args[1])}
+			// a new array, so assigning to an element of one doesn't change the other
+			elements := make([]object.Object, len(l.Elements), len(l.Elements)+1)
+			copy(elements, l.Elements)
+			return &object.Array{ElementType: l.ElementType, Elements: append(elements, args[1])}
 		},
 	},
 	"type": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) == 1 {
-				return &object.String{Value: typeMap[args[0].Type()]}
+				return &object.String{Value: typeName(args[0])}
 			}
 			return NULL
 		},
 	},
 	"item_in": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%q", len(args))
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("item_in", len(args), 2); err != nil {
+				return err
 			}
 			s, ok := args[1].(*object.Array)
 			if !ok {
-				return newError("cannot convert %v to array", args[1])
+				return newKindError(object.TYPE_ERROR, "cannot convert %v to array", args[1])
 			}
-			if s.ElementType != typeMap[args[0].Type()] {
-				return newError("cannot convert %v to array element", args[0])
+			if s.ElementType != typeName(args[0]) {
+				return newKindError(object.TYPE_ERROR, "cannot convert %v to array element", args[0])
 			}
 			for _, i := range s.Elements {
 				if object.Equality(&i, &args[0]) {
-					return &object.Boolean{true}
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	},
+	"keys": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("keys", len(args), 1); err != nil {
+				return err
+			}
+			h, ok := args[0].(*object.Hash)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "argument to 'keys' must be hashmap, got %s", args[0].Type())
+			}
+			array := &object.Array{ElementType: h.KeyType, Elements: []object.Object{}}
+			for _, k := range h.Order {
+				array.Elements = append(array.Elements, h.Pairs[k].Key)
+			}
+			return array
+		},
+	},
+	"values": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("values", len(args), 1); err != nil {
+				return err
+			}
+			h, ok := args[0].(*object.Hash)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "argument to 'values' must be hashmap, got %s", args[0].Type())
+			}
+			array := &object.Array{ElementType: h.ValueType, Elements: []object.Object{}}
+			for _, k := range h.Order {
+				array.Elements = append(array.Elements, h.Pairs[k].Value)
+			}
+			return array
+		},
+	},
+	"has_key": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("has_key", len(args), 2); err != nil {
+				return err
+			}
+			h, ok := args[0].(*object.Hash)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "first argument to 'has_key' must be hashmap, got %s", args[0].Type())
+			}
+			key, err := hashKey(h, args[1])
+			if err != nil {
+				return err
+			}
+			_, ok = h.Pairs[key]
+			return nativeBoolToBooleanObject(ok)
+		},
+	},
+	"error": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs := []string{}
+			for _, arg := range args {
+				str, ok := arg.(*object.String)
+				if !ok {
+					return newKindError(object.TYPE_ERROR, "arguments to 'error' must be strings, got %s", arg.Type())
+				}
+				strs = append(strs, str.Value)
+			}
+			switch len(strs) {
+			case 1:
+				return &object.ErrorValue{Error: newError("%s", strs[0])}
+			case 2:
+				return &object.ErrorValue{Error: newKindError(strs[0], "%s", strs[1])}
+			}
+			return newKindError(object.ARITY_ERROR, "wrong number of arguments to error. got=%d, want=1 or 2", len(args))
+		},
+	},
+	"delete": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("delete", len(args), 2); err != nil {
+				return err
+			}
+			h, ok := args[0].(*object.Hash)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "first argument to 'delete' must be hashmap, got %s", args[0].Type())
+			}
+			key, err := hashKey(h, args[1])
+			if err != nil {
+				return err
+			}
+			result := h.Copy()
+			result.Delete(key)
+			return result
+		},
+	},
+	"map": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("map", len(args), 2); err != nil {
+				return err
+			}
+			array, err := arrayArgument("map", args)
+			if err != nil {
+				return err
+			}
+			elements := make([]object.Object, len(array.Elements))
+			for i, el := range array.Elements {
+				result := ctx.Call(args[1], el)
+				if isError(result) {
+					return result
+				}
+				elements[i] = result
+			}
+			if len(elements) == 0 {
+				// with nothing to go on, the result holds what the function returns
+				return &object.Array{ElementType: returnTypeName(args[1]), Elements: elements}
+			}
+			return NewArray(elements)
+		},
+	},
+	"filter": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("filter", len(args), 2); err != nil {
+				return err
+			}
+			array, err := arrayArgument("filter", args)
+			if err != nil {
+				return err
+			}
+			elements := []object.Object{}
+			for _, el := range array.Elements {
+				keep, err := callPredicate(ctx, "filter", args[1], el)
+				if err != nil {
+					return err
+				}
+				if keep {
+					elements = append(elements, el)
+				}
+			}
+			elementType := array.ElementType
+			if elementType == "" {
+				// an array that doesn't know what it holds, like [], holds what the
+				// function takes
+				elementType = parameterTypeName(args[1])
+			}
+			return &object.Array{ElementType: elementType, Elements: elements}
+		},
+	},
+	"reduce": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("reduce", len(args), 3); err != nil {
+				return err
+			}
+			array, err := arrayArgument("reduce", args)
+			if err != nil {
+				return err
+			}
+			result := args[2]
+			for _, el := range array.Elements {
+				result = ctx.Call(args[1], result, el)
+				if isError(result) {
+					return result
+				}
+			}
+			return result
+		},
+	},
+	"sort": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkArityBetween("sort", len(args), 1, 2); err != nil {
+				return err
+			}
+			array, err := arrayArgument("sort", args)
+			if err != nil {
+				return err
+			}
+			var less func(a object.Object, b object.Object) (bool, *object.Error)
+			if len(args) == 2 {
+				less = func(a object.Object, b object.Object) (bool, *object.Error) {
+					return callPredicate(ctx, "sort", args[1], a, b)
+				}
+			} else {
+				less = lessThan
+			}
+			elements, err := sortElements(array.Elements, less)
+			if err != nil {
+				return err
+			}
+			return &object.Array{ElementType: array.ElementType, Elements: elements}
+		},
+	},
+	"reverse": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("reverse", len(args), 1); err != nil {
+				return err
+			}
+			if str, ok := args[0].(*object.String); ok {
+				runes := []rune(str.Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				return &object.String{Value: string(runes)}
+			}
+			array, err := arrayArgument("reverse", args)
+			if err != nil {
+				return err
+			}
+			elements := make([]object.Object, len(array.Elements))
+			for i, el := range array.Elements {
+				elements[len(elements)-1-i] = el
+			}
+			return &object.Array{ElementType: array.ElementType, Elements: elements}
+		},
+	},
+	"slice": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkArityBetween("slice", len(args), 2, 3); err != nil {
+				return err
+			}
+			end := object.Object(NULL)
+			if len(args) == 3 {
+				end = args[2]
+			}
+			return EvalSliceExpression(args[0], args[1], end)
+		},
+	},
+	"index_of": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("index_of", len(args), 2); err != nil {
+				return err
+			}
+			if _, ok := args[0].(*object.String); ok {
+				// a string is searched for a substring, as find does
+				strs, err := stringArguments("index_of", args)
+				if err != nil {
+					return err
+				}
+				return &object.Integer{Value: runeIndex(strs[0], strs[1])}
+			}
+			array, err := arrayArgument("index_of", args)
+			if err != nil {
+				return err
+			}
+			for i := range array.Elements {
+				if object.Equality(&array.Elements[i], &args[1]) {
+					return &object.Integer{Value: int64(i)}
+				}
+			}
+			return &object.Integer{Value: -1}
+		},
+	},
+	"any": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("any", len(args), 2); err != nil {
+				return err
+			}
+			array, err := arrayArgument("any", args)
+			if err != nil {
+				return err
+			}
+			for _, el := range array.Elements {
+				ok, err := callPredicate(ctx, "any", args[1], el)
+				if err != nil {
+					return err
+				}
+				if ok {
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	},
+	"all": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("all", len(args), 2); err != nil {
+				return err
+			}
+			array, err := arrayArgument("all", args)
+			if err != nil {
+				return err
+			}
+			for _, el := range array.Elements {
+				ok, err := callPredicate(ctx, "all", args[1], el)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return FALSE
+				}
+			}
+			return TRUE
+		},
+	},
+	"zip": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("zip", len(args), 2); err != nil {
+				return err
+			}
+			left, lok := args[0].(*object.Array)
+			right, rok := args[1].(*object.Array)
+			if !lok || !rok {
+				return newKindError(object.TYPE_ERROR, "arguments to 'zip' must be arrays, got %s and %s", args[0].Type(), args[1].Type())
+			}
+			// each pair is an array, so both arrays have to hold the same type
+			if left.ElementType != "" && right.ElementType != "" && left.ElementType != right.ElementType {
+				return newKindError(object.TYPE_ERROR, "arguments to 'zip' must be arrays of the same type, got %s and %s", TypeOf(left), TypeOf(right))
+			}
+			pairs := []object.Object{}
+			for i := 0; i < len(left.Elements) && i < len(right.Elements); i++ {
+				pairs = append(pairs, &object.Array{ElementType: typeName(left.Elements[i]), Elements: []object.Object{left.Elements[i], right.Elements[i]}})
+			}
+			return &object.Array{ElementType: "array", Elements: pairs}
+		},
+	},
+	"enumerate": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("enumerate", len(args), 1); err != nil {
+				return err
+			}
+			array, err := arrayArgument("enumerate", args)
+			if err != nil {
+				return err
+			}
+			// the hashmap keeps the order of the array, so its keys go from 0 up
+			hash := object.NewHash("int", array.ElementType)
+			for i, el := range array.Elements {
+				index := &object.Integer{Value: int64(i)}
+				hash.Set(index.HashKey(), object.HashPair{Key: index, Value: el})
+			}
+			return hash
+		},
+	},
+	"split": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("split", len(args), 2); err != nil {
+				return err
+			}
+			strs, err := stringArguments("split", args)
+			if err != nil {
+				return err
+			}
+			array := &object.Array{ElementType: "string", Elements: []object.Object{}}
+			for _, part := range strings.Split(strs[0], strs[1]) {
+				array.Elements = append(array.Elements, &object.String{Value: part})
+			}
+			return array
+		},
+	},
+	"join": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("join", len(args), 2); err != nil {
+				return err
+			}
+			array, err := arrayArgument("join", args)
+			if err != nil {
+				return err
+			}
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "second argument to 'join' must be string, got %s", args[1].Type())
+			}
+			parts := []string{}
+			for _, el := range array.Elements {
+				part, ok := el.(*object.String)
+				if !ok {
+					return newKindError(object.TYPE_ERROR, "can only join strings, got %s", el.Type())
 				}
+				parts = append(parts, part.Value)
+			}
+			return &object.String{Value: strings.Join(parts, sep.Value)}
+		},
+	},
+	"trim":        stringBuiltin("trim", strings.TrimSpace),
+	"upper":       stringBuiltin("upper", strings.ToUpper),
+	"lower":       stringBuiltin("lower", strings.ToLower),
+	"contains":    stringTest("contains", strings.Contains),
+	"starts_with": stringTest("starts_with", strings.HasPrefix),
+	"ends_with":   stringTest("ends_with", strings.HasSuffix),
+	"replace": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("replace", len(args), 3); err != nil {
+				return err
+			}
+			strs, err := stringArguments("replace", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+		},
+	},
+	"find": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("find", len(args), 2); err != nil {
+				return err
+			}
+			strs, err := stringArguments("find", args)
+			if err != nil {
+				return err
+			}
+			return &object.Integer{Value: runeIndex(strs[0], strs[1])}
+		},
+	},
+	"repeat": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("repeat", len(args), 2); err != nil {
+				return err
+			}
+			strs, err := stringArguments("repeat", args[:1])
+			if err != nil {
+				return err
+			}
+			count, ok := args[1].(*object.Integer)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "second argument to 'repeat' must be int, got %s", args[1].Type())
+			}
+			if count.Value < 0 {
+				return newKindError(object.VALUE_ERROR, "can't repeat a string %d times", count.Value)
+			}
+			if count.Value > 0 && int64(len(strs[0])) > maxStringLength/count.Value {
+				return newKindError(object.OVERFLOW_ERROR, "repeated string is too long")
+			}
+			return &object.String{Value: strings.Repeat(strs[0], int(count.Value))}
+		},
+	},
+	"substring": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkArityBetween("substring", len(args), 2, 3); err != nil {
+				return err
+			}
+			if _, err := stringArguments("substring", args[:1]); err != nil {
+				return err
+			}
+			end := object.Object(NULL)
+			if len(args) == 3 {
+				end = args[2]
+			}
+			return EvalSliceExpression(args[0], args[1], end)
+		},
+	},
+	"pad_left":  padBuiltin("pad_left", true),
+	"pad_right": padBuiltin("pad_right", false),
+	"chr": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("chr", len(args), 1); err != nil {
+				return err
+			}
+			code, ok := args[0].(*object.Integer)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "argument to 'chr' must be int, got %s", args[0].Type())
+			}
+			if code.Value < 0 || code.Value > unicode.MaxRune || !utf8.ValidRune(rune(code.Value)) {
+				return newKindError(object.VALUE_ERROR, "%d is not a valid code point", code.Value)
+			}
+			return &object.String{Value: string(rune(code.Value))}
+		},
+	},
+	"ord": &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity("ord", len(args), 1); err != nil {
+				return err
+			}
+			strs, err := stringArguments("ord", args)
+			if err != nil {
+				return err
+			}
+			if utf8.RuneCountInString(strs[0]) != 1 {
+				return newKindError(object.VALUE_ERROR, "argument to 'ord' must be one character, got %d characters", utf8.RuneCountInString(strs[0]))
 			}
-			return &object.Boolean{false}
+			r, _ := utf8.DecodeRuneInString(strs[0])
+			return &object.Integer{Value: int64(r)}
 		},
 	},
 }
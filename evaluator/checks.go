@@ -0,0 +1,515 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/module"
+	"github.com/OisinA/Azula/object"
+)
+
+// The checks in this file are done while a program runs, by both the evaluator and the vm.
+
+// CheckLet checks the value being assigned by a let statement against its declared type
+func CheckLet(node *ast.LetStatement, val object.Object) *object.Error {
+	if val.Type() == object.ARRAY_OBJ {
+		array := val.(*object.Array)
+		if node.Token.Literal == "array" {
+			takeElementType(array, node.Name.ReturnType.Value)
+		}
+		if !isArrayOf(array, node.Name.ReturnType.Value) {
+			return newError("trying to assign array %s to array %s: "+node.Name.Value, array.ElementType, node.Name.ReturnType.Value)
+		}
+		return nil
+	}
+	if hash, ok := val.(*object.Hash); ok && node.Token.Literal == "hashmap" {
+		if len(hash.Pairs) == 0 {
+			// an empty literal takes on the declared key and value types
+			hash.KeyType, hash.ValueType = splitHashType(node.Name.ReturnType.Value)
+		}
+		if !isHashOf(hash, node.Name.ReturnType.Value) {
+			return newError("trying to assign hashmap(%s) to hashmap(%s): "+node.Name.Value, hashTypeName(hash), node.Name.ReturnType.Value)
+		}
+		return nil
+	}
+	if node.Token.Literal == "func" {
+		if !isFunctionOf(val, ast.TypeString(&node.Name.ReturnType)) {
+			return newError("trying to assign %s to %s: "+node.Name.Value, TypeOf(val), ast.TypeString(&node.Name.ReturnType))
+		}
+		return nil
+	}
+	if !isType(val, node.Token.Literal) {
+		return newError("trying to assign %s to %s: "+node.Name.Value, typeName(val), node.Token.Literal)
+	}
+	return nil
+}
+
+// CheckReassign checks a new value for a variable has the same type as its old one
+func CheckReassign(old object.Object, val object.Object) *object.Error {
+	if typeName(old) == typeName(val) {
+		return nil
+	}
+	// without the declared type, instances of classes with a common ancestor or
+	// interface are allowed
+	if instance, ok := old.(*object.Instance); ok {
+		for class := instance.Class; class != nil; class = class.Parent {
+			if isType(val, class.Name.Value) {
+				return nil
+			}
+			for _, iface := range class.Interfaces {
+				if isType(val, iface.Name.Value) {
+					return nil
+				}
+			}
+		}
+	}
+	return newError("can't assign value of type %s to variable of type %s", typeName(old), typeName(val))
+}
+
+// CheckReturnType checks the result of calling the named function against its declared
+// return type, giving the value the call evaluates to
+func CheckReturnType(name string, returnType *ast.Identifier, result object.Object) object.Object {
+	if returnType.Token.Literal == "void" {
+		return NULL
+	}
+	if result == nil {
+		result = NULL
+	}
+	if isType(result, returnType.Token.Literal) {
+		if returnType.Token.Literal == "array" {
+			array := result.(*object.Array)
+			takeElementType(array, returnType.Value)
+			if !isArrayOf(array, returnType.Value) {
+				return newError("function %s returned array(%s), not array(%s)", name, array.ElementType, returnType.Value)
+			}
+		}
+		if returnType.Token.Literal == "hashmap" {
+			hash := result.(*object.Hash)
+			if !isHashOf(hash, returnType.Value) {
+				return newError("function %s returned hashmap(%s), not hashmap(%s)", name, hashTypeName(hash), returnType.Value)
+			}
+		}
+		if returnType.Token.Literal == "func" && !isFunctionOf(result, returnType.Value) {
+			return newError("function %s returned %s, not %s", name, TypeOf(result), returnType.Value)
+		}
+		return result
+	}
+	return newError("function %s returned %s, not %s", name, typeName(result), returnType.Token.Literal)
+}
+
+// CheckArity checks a function or class is called with as many arguments as it takes
+func CheckArity(name string, got int, want int) *object.Error {
+	if got != want {
+		return newKindError(object.ARITY_ERROR, "wrong number of arguments to %s. got=%d, want=%d", name, got, want)
+	}
+	return nil
+}
+
+// checkArityBetween is CheckArity for a builtin whose last argument can be left out
+func checkArityBetween(name string, got int, min int, max int) *object.Error {
+	if got < min || got > max {
+		return newKindError(object.ARITY_ERROR, "wrong number of arguments to %s. got=%d, want=%d or %d", name, got, min, max)
+	}
+	return nil
+}
+
+// RecursionError is raised by a call that would go deeper than the program is allowed to
+func RecursionError(function string) *object.Error {
+	return newKindError(object.RECURSION_ERROR, "maximum recursion depth exceeded in %s", function)
+}
+
+// StepLimitError is raised once a program has run for as many steps as it's allowed to
+func StepLimitError(max int) *object.Error {
+	return newKindError(object.STEP_LIMIT_ERROR, "maximum number of steps exceeded (%d)", max)
+}
+
+// Throw gives the error raised by a throw statement, which throws either a message or
+// an error value. An error value keeps where it was first raised when thrown again.
+func Throw(val object.Object) *object.Error {
+	switch val := val.(type) {
+	case *object.String:
+		return newError("%s", val.Value)
+	case *object.ErrorValue:
+		return val.Error.Copy()
+	}
+	return newKindError(object.TYPE_ERROR, "can only throw a string or an error, not %s", typeName(val))
+}
+
+// CatchMatches reports whether a catch clause catches an error. A clause without a
+// kind catches every error.
+func CatchMatches(clause *ast.CatchClause, err *object.Error) bool {
+	return clause.Kind == nil || clause.Kind.Value == err.Kind
+}
+
+// CheckImplements checks a class has every method of the interfaces it implements,
+// with the same parameter and return types
+func CheckImplements(class *object.Class) *object.Error {
+	for _, iface := range class.Interfaces {
+		for _, sig := range iface.Methods {
+			method := findMethod(class, sig.Name.Value)
+			if method == nil {
+				return newError("class %s doesn't implement %s: missing method %s", class.Name.Value, iface.Name.Value, sig.Name.Value)
+			}
+			got, want := signatureOf(method.Parameters, method.ReturnType), signatureOf(sig.Parameters, sig.ReturnType)
+			if got != want {
+				return newError("class %s doesn't implement %s: method %s is %s, not %s", class.Name.Value, iface.Name.Value, sig.Name.Value, got, want)
+			}
+		}
+	}
+	return nil
+}
+
+// findMethod finds the method with the given name declared by a class or the classes it inherits from
+func findMethod(class *object.Class, name string) *ast.FunctionLiteral {
+	for ; class != nil; class = class.Parent {
+		for _, s := range class.Body.Statements {
+			es, ok := s.(*ast.ExpressionStatement)
+			if !ok {
+				continue
+			}
+			if fn, ok := es.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil && fn.Name.Value == name {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+// signatureOf gives the type of a function with the given parameters and return type,
+// as in func(int, string): bool
+func signatureOf(params []*ast.TypedIdentifier, returnType *ast.Identifier) string {
+	types := []string{}
+	for _, p := range params {
+		types = append(types, ast.TypeString(&p.ReturnType))
+	}
+	ret := "void"
+	if returnType != nil {
+		ret = ast.TypeString(returnType)
+	}
+	return "func(" + strings.Join(types, ", ") + "): " + ret
+}
+
+// isFunctionOf reports whether a value can be used where a function of the given type
+// is declared. As in the checker, the function has to take whatever the declared type's
+// parameters are, and return something its return type can hold.
+func isFunctionOf(obj object.Object, t string) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Closure:
+		return isAssignableType(t, TypeOf(obj))
+	case *object.Builtin:
+		// builtins don't declare their types, so can be used as any function
+		return true
+	}
+	return false
+}
+
+// isAssignableType reports whether a value of the type written as from can be used where
+// the type written as to is declared. How the classes named in the types are related
+// isn't known while the program runs, so any class is taken to fit any other, leaving
+// the checker to tell them apart.
+func isAssignableType(to string, from string) bool {
+	if to == from {
+		return true
+	}
+	toParams, toReturn, ok := ast.SplitFunctionType(to)
+	fromParams, fromReturn, fromOk := ast.SplitFunctionType(from)
+	if ok && fromOk {
+		if len(toParams) != len(fromParams) {
+			return false
+		}
+		for i := range toParams {
+			if !isAssignableType(fromParams[i], toParams[i]) {
+				return false
+			}
+		}
+		return isAssignableType(toReturn, fromReturn)
+	}
+	return isClassName(to) && isClassName(from)
+}
+
+// isClassName reports whether a type is named by a class or interface, rather than being
+// one of the language's own
+func isClassName(name string) bool {
+	if strings.Contains(name, "(") || name == "array" || name == "hashmap" {
+		return false
+	}
+	for _, builtin := range typeMap {
+		if builtin == name {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportName gives the name an import binds its module to, which is the module's
+// file name if the import doesn't give one
+func ImportName(node *ast.ImportStatement, file string) (string, *object.Error) {
+	if node.Alias != nil {
+		return node.Alias.Value, nil
+	}
+	name := module.Name(file)
+	if !module.IsValidName(name) {
+		return "", newError("can't use %s as the name of a module, import it with as", name)
+	}
+	return name, nil
+}
+
+// NewArray builds an array literal, checking all of its elements have the same type
+func NewArray(elements []object.Object) object.Object {
+	var t string
+	for _, tt := range elements {
+		if t == "" {
+			t = typeName(tt)
+			continue
+		}
+		if t != typeName(tt) {
+			return newError("trying to assign %s to array of %s", typeName(tt), t)
+		}
+	}
+	return &object.Array{ElementType: t, Elements: elements}
+}
+
+// NewHash builds a hashmap literal from its keys and values, checking they all have the
+// same types as the first pair
+func NewHash(keys []object.Object, values []object.Object) object.Object {
+	hash := object.NewHash("", "")
+
+	for i, key := range keys {
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hashmap key: %s", key.Type())
+		}
+
+		value := values[i]
+		if i == 0 {
+			hash.KeyType = typeName(key)
+			hash.ValueType = typeName(value)
+		} else if hash.KeyType != typeName(key) || hash.ValueType != typeName(value) {
+			return newError("trying to add %s: %s to hashmap(%s)", typeName(key), typeName(value), hashTypeName(hash))
+		}
+
+		hash.Set(hashable.HashKey(), object.HashPair{Key: key, Value: value})
+	}
+
+	return hash
+}
+
+// NewError builds an error object from a format string
+func NewError(format string, a ...interface{}) *object.Error {
+	return newError(format, a...)
+}
+
+// GetField gives the value of a field of an instance
+func GetField(obj object.Object, name string) object.Object {
+	return getProperty(obj, name, "field")
+}
+
+// GetMethod gives the method of an instance with the given name
+func GetMethod(obj object.Object, name string) object.Object {
+	return getProperty(obj, name, "method")
+}
+
+func getProperty(obj object.Object, name string, kind string) object.Object {
+	if val, ok := obj.(*object.ErrorValue); ok && kind == "field" {
+		return errorField(val.Error, name)
+	}
+	if mod, ok := obj.(*object.Module); ok {
+		if val, ok := mod.Get(name); ok && module.Exported(name) {
+			return val
+		}
+		return newError("module %s doesn't export %s", mod.Name, name)
+	}
+	instance, ok := obj.(*object.Instance)
+	if !ok {
+		return newError("can't get %s %s of %s, it is not an object", kind, name, obj.Type())
+	}
+	if val, ok := instance.Get(name); ok {
+		return val
+	}
+	return newError("class %s has no %s %s", instance.Class.Name.Value, kind, name)
+}
+
+// errorField gives a field of an error value: its message, kind, or the stack of call
+// sites it was raised through
+func errorField(err *object.Error, name string) object.Object {
+	switch name {
+	case "message":
+		return &object.String{Value: err.Message}
+	case "kind":
+		return &object.String{Value: err.Kind}
+	case "stack":
+		// the innermost function comes first
+		stack := &object.Array{ElementType: "string", Elements: []object.Object{}}
+		frames := err.Traceback()
+		for i := len(frames) - 1; i >= 0; i-- {
+			stack.Elements = append(stack.Elements, &object.String{Value: frames[i].Function + " at " + frames[i].Pos.String()})
+		}
+		return stack
+	}
+	return newError("error has no field %s", name)
+}
+
+// SetField sets an existing field of an instance, checking the new value has the same type as the old one
+func SetField(obj object.Object, name string, val object.Object) *object.Error {
+	if mod, ok := obj.(*object.Module); ok {
+		return newError("can't assign to %s of module %s", name, mod.Name)
+	}
+	if _, ok := obj.(*object.ErrorValue); ok {
+		return newError("can't assign to %s of an error", name)
+	}
+	old := GetField(obj, name)
+	if err, ok := old.(*object.Error); ok {
+		return err
+	}
+	if err := CheckReassign(old, val); err != nil {
+		return err
+	}
+	obj.(*object.Instance).Set(name, val)
+	return nil
+}
+
+// SetIndex sets an element of an array, or the value of a key of a hashmap, checking
+// the index is there to set and the value is of the type the others are
+func SetIndex(left object.Object, index object.Object, val object.Object) *object.Error {
+	switch left := left.(type) {
+	case *object.Array:
+		i, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be int, not %s", typeName(index))
+		}
+		idx := i.Value
+		if idx < 0 {
+			idx = int64(len(left.Elements)) + idx
+		}
+		if idx < 0 || idx >= int64(len(left.Elements)) {
+			return newKindError(object.INDEX_ERROR, "index out of bounds")
+		}
+		if err := CheckReassign(left.Elements[idx], val); err != nil {
+			return err
+		}
+		left.Elements[idx] = val
+		return nil
+	case *object.Hash:
+		key, err := hashKey(left, index)
+		if err != nil {
+			return err
+		}
+		if left.ValueType != "" && !isType(val, left.ValueType) {
+			return newError("trying to add %s: %s to hashmap(%s)", typeName(index), typeName(val), hashTypeName(left))
+		}
+		left.Set(key, object.HashPair{Key: index, Value: val})
+		return nil
+	case *object.String:
+		return newKindError(object.TYPE_ERROR, "can't assign to a character of a string")
+	}
+	return newError("index operator not supported: %s", left.Type())
+}
+
+// isType reports whether a value can be used where the named type is declared,
+// which for an instance includes the classes it inherits from. The name can be
+// written out in full, as in array(int), to check what an array or hashmap holds
+// or a function's signature.
+func isType(obj object.Object, name string) bool {
+	switch obj := obj.(type) {
+	case *object.Instance:
+		return obj.IsA(name)
+	case *object.Array:
+		if strings.HasPrefix(name, "array(") {
+			return isArrayOf(obj, name[len("array("):len(name)-1])
+		}
+	case *object.Hash:
+		if strings.HasPrefix(name, "hashmap(") {
+			return isHashOf(obj, name[len("hashmap("):len(name)-1])
+		}
+	case *object.Function, *object.Closure, *object.Builtin:
+		if strings.HasPrefix(name, "func(") {
+			return isFunctionOf(obj, name)
+		}
+	}
+	return typeName(obj) == name
+}
+
+// isArrayOf reports whether an array can be used where an array of elem is declared
+func isArrayOf(array *object.Array, elem string) bool {
+	if array.ElementType == elem {
+		return true
+	}
+	if len(array.Elements) == 0 {
+		// the elements of an array of arrays, hashmaps or functions are only known by
+		// their kind, as in array, so an empty one can be taken as holding any of them
+		return strings.HasPrefix(elem, array.ElementType+"(")
+	}
+	for _, el := range array.Elements {
+		if !isType(el, elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// takeElementType gives an empty array that doesn't know what it holds, like the empty
+// literal [], the element type it's declared with
+func takeElementType(array *object.Array, elem string) {
+	if array.ElementType == "" && len(array.Elements) == 0 {
+		array.ElementType = elem
+	}
+}
+
+// isHashOf reports whether a hashmap can be used where a hashmap of the given key and
+// value types, as in "string, int", is declared
+func isHashOf(hash *object.Hash, types string) bool {
+	if hashTypeName(hash) == types {
+		return true
+	}
+	if len(hash.Pairs) == 0 {
+		return false
+	}
+	key, value := splitHashType(types)
+	for _, pair := range hash.Pairs {
+		if !isType(pair.Key, key) || !isType(pair.Value, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// typeName gives the name of the type of a value, as it is written in a program
+func typeName(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.Instance:
+		return obj.Class.Name.Value
+	case *object.Class:
+		return "class"
+	case *object.Interface:
+		return "interface"
+	case *object.Module:
+		return "module"
+	}
+	return typeMap[obj.Type()]
+}
+
+// TypeOf gives the type of a value as a program would declare it, including what an
+// array or hashmap holds and a function's signature
+func TypeOf(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.Array:
+		if obj.ElementType != "" {
+			return "array(" + obj.ElementType + ")"
+		}
+	case *object.Hash:
+		if obj.KeyType != "" {
+			return "hashmap(" + obj.KeyType + ", " + obj.ValueType + ")"
+		}
+	case *object.Function:
+		return signatureOf(obj.Parameters, obj.ReturnType)
+	case *object.Closure:
+		return signatureOf(obj.Fn.Parameters, obj.Fn.ReturnType)
+	}
+	return typeName(obj)
+}
+
+// LookupBuiltin gives the builtin function with the given name
+func LookupBuiltin(name string) (*object.Builtin, bool) {
+	builtin, ok := builtins[name]
+	return builtin, ok
+}
@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/OisinA/Azula/object"
+)
+
+// arrayArgument gives the array a builtin like map is given as its first argument
+func arrayArgument(name string, args []object.Object) (*object.Array, *object.Error) {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		which := "first argument"
+		if len(args) == 1 {
+			which = "argument"
+		}
+		return nil, newKindError(object.TYPE_ERROR, "%s to '%s' must be array, got %s", which, name, args[0].Type())
+	}
+	return array, nil
+}
+
+// callPredicate calls a function given to a builtin like filter, which has to return a bool
+func callPredicate(ctx *object.Context, name string, fn object.Object, args ...object.Object) (bool, *object.Error) {
+	result := ctx.Call(fn, args...)
+	if err, ok := result.(*object.Error); ok {
+		return false, err
+	}
+	b, ok := result.(*object.Boolean)
+	if !ok {
+		return false, newKindError(object.TYPE_ERROR, "function given to '%s' must return bool, got %s", name, typeName(result))
+	}
+	return b.Value, nil
+}
+
+// returnTypeName gives the name of the type a function declares it returns, if it does
+func returnTypeName(fn object.Object) string {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if fn.ReturnType != nil {
+			return fn.ReturnType.Token.Literal
+		}
+	case *object.Closure:
+		if fn.Fn.ReturnType != nil {
+			return fn.Fn.ReturnType.Token.Literal
+		}
+	}
+	return ""
+}
+
+// parameterTypeName gives the name of the type of a function's first parameter, if it
+// declares one
+func parameterTypeName(fn object.Object) string {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(fn.Parameters) > 0 {
+			return fn.Parameters[0].ReturnType.Token.Literal
+		}
+	case *object.Closure:
+		if len(fn.Fn.Parameters) > 0 {
+			return fn.Fn.Parameters[0].ReturnType.Token.Literal
+		}
+	}
+	return ""
+}
+
+// sortElements gives a sorted copy of elements, keeping the order of equal elements.
+// The first error comparing them stops the sort.
+func sortElements(elements []object.Object, less func(a object.Object, b object.Object) (bool, *object.Error)) ([]object.Object, *object.Error) {
+	sorted := append([]object.Object{}, elements...)
+	var failed *object.Error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if failed != nil {
+			return false
+		}
+		ok, err := less(sorted[i], sorted[j])
+		if err != nil {
+			failed = err
+		}
+		return ok
+	})
+	if failed != nil {
+		return nil, failed
+	}
+	return sorted, nil
+}
+
+// lessThan orders numbers and strings, which sort can order without a function
+func lessThan(a object.Object, b object.Object) (bool, *object.Error) {
+	switch a := a.(type) {
+	case *object.Integer:
+		if b, ok := b.(*object.Integer); ok {
+			return a.Value < b.Value, nil
+		}
+	case *object.Float:
+		if b, ok := b.(*object.Float); ok {
+			return a.Value < b.Value, nil
+		}
+	case *object.BigInt:
+		if b, ok := b.(*object.BigInt); ok {
+			return a.Value.Cmp(b.Value) < 0, nil
+		}
+	case *object.String:
+		if b, ok := b.(*object.String); ok {
+			return strings.Compare(a.Value, b.Value) < 0, nil
+		}
+	}
+	return false, newKindError(object.TYPE_ERROR, "can't sort %s without a function to compare them", typeName(a))
+}
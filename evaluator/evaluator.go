@@ -2,28 +2,54 @@ package evaluator
 
 import (
 	"fmt"
-	"io/ioutil"
+	"math"
+	"math/big"
 
 	"github.com/OisinA/Azula/ast"
-	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/module"
 	"github.com/OisinA/Azula/object"
-	"github.com/OisinA/Azula/parser"
+	"github.com/OisinA/Azula/token"
 )
 
+// Every program shares these, which is safe as nothing changes them
 var (
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
 	NULL  = &object.Null{}
 
 	typeMap = map[object.ObjectType]string{
-		object.INTEGER_OBJ: "int",
-		object.BOOLEAN_OBJ: "bool",
-		object.STRING_OBJ:  "string",
-		object.ARRAY_OBJ:   "array",
+		object.INTEGER_OBJ:     "int",
+		object.FLOAT_OBJ:       "float",
+		object.BIGINT_OBJ:      "bigint",
+		object.BOOLEAN_OBJ:     "bool",
+		object.STRING_OBJ:      "string",
+		object.ARRAY_OBJ:       "array",
+		object.HASH_OBJ:        "hashmap",
+		object.ERROR_VALUE_OBJ: "error",
+		object.FUNCTION_OBJ:    "func",
+		object.CLOSURE_OBJ:     "func",
+		object.BUILTIN_OBJ:     "func",
+		// what a function that returns nothing gives
+		object.NULL_OBJ: "void",
 	}
 )
 
+// Eval evaluates the node, tagging any error that has no position with the position of the node
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if limits := env.Limits(); limits.MaxSteps > 0 {
+		limits.Steps++
+		if limits.Steps > limits.MaxSteps {
+			return StepLimitError(limits.MaxSteps)
+		}
+	}
+	result := eval(node, env)
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() && node != nil {
+		err.Pos = node.Pos()
+	}
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
@@ -40,96 +66,74 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		return &object.ReturnValue{Value: val}
+		return &object.ReturnValue{Value: val, Pos: node.Token.Pos}
 
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		if val.Type() == object.ARRAY_OBJ {
-			array := val.(*object.Array)
-			if node.Name.ReturnType.Value != array.ElementType {
-				return newError("trying to assign array %s to array %s: "+node.Name.Value, array.ElementType, node.Name.ReturnType.Value)
-			}
-			env.Set(node.Name.Value, val)
-			return NULL
-		}
-		if val.Type() == object.CLASS_OBJ {
-			class := val.(*object.Class)
-			if node.Token.Literal != class.Name.String() {
-				return newError("can't assign to type %s", node.Token.Literal)
-			}
-			env.Set(node.Name.Value, val)
-			return NULL
-		}
-		if typeMap[val.Type()] == node.Token.Literal {
-			env.Set(node.Name.Value, val)
-			return NULL
-		} else {
-			return newError("trying to assign %s to %s: "+node.Name.Value, typeMap[val.Type()], node.Token.Literal)
+		if err := CheckLet(node, val); err != nil {
+			return err
 		}
+		env.Set(node.Name.Value, val)
+		return NULL
 
 	case *ast.ReassignStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
 		obj, ok := env.Get(node.Name.Value)
 		if !ok {
 			return newError("can't reassign value to non-existent variable '" + node.Name.Value + "'")
 		}
-		if typeMap[obj.Type()] != typeMap[val.Type()] {
-			return newError("can't assign value of type %s to variable of type %s", typeMap[obj.Type()], typeMap[val.Type()])
+		val := evalAssignedValue(node.Operator, obj, node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if err := CheckReassign(obj, val); err != nil {
+			return err
 		}
 		env.Overwrite(node.Name.Value, val)
 
 	case *ast.ImportStatement:
+		return evalImport(node, env)
+
+	case *ast.TryStatement:
+		return evalTry(node, env)
+
+	case *ast.ThrowStatement:
 		val := Eval(node.Value, env)
-		v, ok := val.(*object.String)
-		if !ok {
-			return newError("invalid import path")
-		}
-		path := v.Value
-		dat, err := ioutil.ReadFile(path)
-		if err != nil {
-			return newError("couldn't import file '%s'", path)
-		}
-		l := lexer.New(string(dat))
-		p := parser.New(l)
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			return newError("something went wrong while importing '%s'", path)
+		if isError(val) {
+			return val
 		}
-		Eval(program, env)
-		return NULL
+		return Throw(val)
 
 	// Expressions
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		parts := evalExpressions(node.Parts, env)
+		if len(parts) == 1 && isError(parts[0]) {
+			return parts[0]
+		}
+		return Interpolate(parts)
 
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
-		var t string
-		for _, tt := range elements {
-			if t == "" {
-				t = typeMap[tt.Type()]
-				continue
-			}
-			if t != typeMap[tt.Type()] {
-				return newError("trying to assign %s to array of %s", typeMap[tt.Type()], t)
-			}
-		}
-		return &object.Array{ElementType: t, Elements: elements}
+		return NewArray(elements)
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
 
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
@@ -139,20 +143,23 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return EvalPrefixExpression(node.Operator, right)
 
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, left, env)
+		}
 
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return EvalInfixExpression(node.Operator, left, right)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.TypedIdentifier:
@@ -167,74 +174,99 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(index) {
 			return index
 		}
-		return evalIndexExpression(left, index)
+		return EvalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		bounds := []object.Object{NULL, NULL}
+		for i, bound := range []ast.Expression{node.Start, node.End} {
+			if bound == nil {
+				continue
+			}
+			if bounds[i] = Eval(bound, env); isError(bounds[i]) {
+				return bounds[i]
+			}
+		}
+		return EvalSliceExpression(left, bounds[0], bounds[1])
 
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		function := &object.Function{Name: node.Name, Parameters: params, Env: env, Body: body, ReturnType: node.ReturnType}
-		env.Set(node.Name.Token.Literal, function)
+		name := node.Name
+		if name == nil {
+			name = &ast.Identifier{Token: node.Token, Value: node.FunctionName()}
+		}
+		function := &object.Function{Name: name, Parameters: params, Env: env, Body: body, ReturnType: node.ReturnType, Pos: node.Token.Pos}
+		if node.Name != nil {
+			env.Set(node.Name.Token.Literal, function)
+		}
 		return function
 
 	case *ast.ClassLiteral:
 		params := node.Parameters
 		body := node.Body
-		class := &object.Class{Name: node.Name, Parameters: params, Env: object.NewEnvironment(), Body: body}
+		class := &object.Class{Name: node.Name, Parameters: params, Env: env, Body: body, ParentArguments: node.ParentArguments}
+		if node.Parent != nil {
+			parent, ok := Eval(node.Parent, env).(*object.Class)
+			if !ok {
+				return newError("%s is not a class", node.Parent.Value)
+			}
+			class.Parent = parent
+		}
+		for _, name := range node.Interfaces {
+			iface, ok := Eval(name, env).(*object.Interface)
+			if !ok {
+				return newError("%s is not an interface", name.Value)
+			}
+			class.Interfaces = append(class.Interfaces, iface)
+		}
+		if err := CheckImplements(class); err != nil {
+			return err
+		}
 		env.Set(node.Name.Token.Literal, class)
 		return class
 
+	case *ast.InterfaceLiteral:
+		iface := &object.Interface{Name: node.Name, Methods: node.Methods}
+		env.Set(node.Name.Value, iface)
+		return iface
+
 	case *ast.CallExpression:
-		newEnv := env
 		if node.Outer != nil {
-			outer := node.Outer
-			classEnv, ok := env.Get(outer.TokenLiteral())
-			if !ok {
-				return newError("couldn't find object %s", outer.TokenLiteral())
-			}
-			class, ok := classEnv.(*object.Class)
-			if !ok {
-				return newError("'%s' is not a class", node.Function.TokenLiteral())
-			}
-			if class.Env != nil {
-				newEnv = class.Env
-			} else {
-				return newError("'%s' is not an object", node.Function.TokenLiteral())
-			}
+			return evalMethodCall(node, env)
 		}
-		function := Eval(node.Function, newEnv)
+		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, newEnv)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
+		return callFunction(function, node.Arguments, env, node.Pos())
+
+	case *ast.PropertyExpression:
+		obj := Eval(node.Object, env)
+		if isError(obj) {
+			return obj
 		}
-		switch fn := function.(type) {
-		case *object.Function:
-			fn = function.(*object.Function)
-			result := applyFunction(function, args)
-			if fn.ReturnType.Token.Literal == "void" {
-				return NULL
-			}
-			if typeMap[result.Type()] == fn.ReturnType.Token.Literal {
-				if fn.ReturnType.Token.Literal == "array" {
-					array := result.(*object.Array)
-					if array.ElementType != fn.ReturnType.Value {
-						return newError("function %s returned array(%s), not array(%s)", fn.Name.String(), array.ElementType, fn.ReturnType.Value)
-					}
-				}
-				return result
-			} else {
-				if result.Type() == object.CLASS_OBJ {
-					if _, ok := env.Get(fn.ReturnType.Token.Literal); ok {
-						return result
-					}
-				}
-				return newError("function %s returned %s, not %s", fn.Name.String(), typeMap[result.Type()], fn.ReturnType.Token.Literal)
-			}
-		default:
-			return applyFunction(function, args)
+		return GetField(obj, node.Property.Value)
+
+	case *ast.PropertyAssignment:
+		return evalPropertyAssignment(node, env)
+
+	case *ast.IndexAssignment:
+		return evalIndexAssignment(node, env)
+
+	case *ast.ThisExpression:
+		if this, ok := env.Get("this"); ok {
+			return this
+		}
+		return newError("this outside of a class")
+
+	case *ast.SuperExpression:
+		if super, ok := env.Get("super"); ok {
+			return super
 		}
+		return newError("super outside of a subclass")
 	case *ast.ForLiteral:
 		obj := Eval(node.Iterator, env)
 		if isError(obj) {
@@ -249,11 +281,41 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		for i := 0; i < len(forLoop.Elements); i++ {
 			env1.Set(node.Parameter.String(), forLoop.Elements[i])
 			result = Eval(node.Body, env1)
+			if stop, value := loopControl(result); stop {
+				return value
+			}
+		}
+		if result == nil || result.Type() == object.CONTINUE_OBJ {
+			result = NULL
+		}
+		return result
+
+	case *ast.WhileLiteral:
+		env1 := object.NewEnclosedEnvironment(env)
+		var result object.Object = NULL
+		for {
+			condition := Eval(node.Condition, env1)
+			if isError(condition) {
+				return condition
+			}
+			if !IsTruthy(condition) {
+				break
+			}
+			result = Eval(node.Body, env1)
+			if stop, value := loopControl(result); stop {
+				return value
+			}
 		}
-		if result == nil {
+		if result == nil || result.Type() == object.CONTINUE_OBJ {
 			result = NULL
 		}
 		return result
+
+	case *ast.BreakStatement:
+		return &object.Break{}
+
+	case *ast.ContinueStatement:
+		return &object.Continue{}
 	}
 	return nil
 }
@@ -269,6 +331,10 @@ func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 			return result.Value
 		case *object.Error:
 			return result
+		case *object.Break, *object.Continue:
+			err := newError("%s outside of a loop", result.Inspect())
+			err.Pos = statement.Pos()
+			return err
 		}
 	}
 
@@ -283,7 +349,7 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -292,47 +358,92 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	return result
 }
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+// loopControl looks at the result of running a loop body, and reports whether the loop
+// should stop along with the value the loop should give if it does
+func loopControl(result object.Object) (bool, object.Object) {
+	if result == nil {
+		return false, nil
+	}
+	switch result.Type() {
+	case object.BREAK_OBJ:
+		return true, NULL
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return true, result
+	}
+	return false, nil
+}
+
+// evalLogicalExpression gives the value of && or || once its left side is known. The
+// right side is only evaluated if the left doesn't decide the result by itself.
+func evalLogicalExpression(node *ast.InfixExpression, left object.Object, env *object.Environment) object.Object {
+	if IsTruthy(left) == (node.Operator == "||") {
+		return nativeBoolToBooleanObject(IsTruthy(left))
+	}
+	return Eval(node.Right, env)
+}
+
+func EvalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		switch right := right.(type) {
+		case *object.Integer:
+			return &object.Integer{Value: ^right.Value}
+		case *object.BigInt:
+			return &object.BigInt{Value: new(big.Int).Not(right.Value)}
+		}
+		return newError("unknown operator: ~%s", right.Type())
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return newKindError(object.OVERFLOW_ERROR, "integer overflow: -(%d)", right.Value)
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return &object.BigInt{Value: new(big.Int).Neg(right.Value)}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalBangOperatorExpression(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
-	case NULL:
+	switch right := right.(type) {
+	case *object.Boolean:
+		return nativeBoolToBooleanObject(!right.Value)
+	case *object.Null:
 		return TRUE
 	default:
 		return FALSE
 	}
 }
 
-func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+func EvalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ:
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.BIGINT_OBJ && right.Type() == object.BIGINT_OBJ:
+		return evalBigIntInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// numbers of different types have to be converted explicitly, with to_int,
+		// to_float or to_bigint
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	case operator == "==":
 		return nativeBoolToBooleanObject(object.Equality(&left, &right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equality(&left, &right))
 	case left.Type() != right.Type() && operator != "+":
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	case operator == "+":
@@ -358,17 +469,59 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		return AddIntegers(leftVal, rightVal)
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return SubtractIntegers(leftVal, rightVal)
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return MultiplyIntegers(leftVal, rightVal)
+	case "/", "%":
+		return DivideIntegers(operator, leftVal, rightVal)
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<", ">>":
+		return ShiftIntegers(operator, leftVal, rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := left.(*object.Float).Value
+	rightVal := right.(*object.Float).Value
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		return &object.Float{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -378,6 +531,72 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
+// evalAssignedValue gives the value an assignment sets, which for a compound assignment
+// is the operator applied to the old value and the one given
+func evalAssignedValue(operator string, old object.Object, value ast.Expression, env *object.Environment) object.Object {
+	if isError(old) {
+		return old
+	}
+	val := Eval(value, env)
+	if isError(val) || operator == "" {
+		return val
+	}
+	return EvalInfixExpression(operator, old, val)
+}
+
+// evalPropertyAssignment sets a field. A plain assignment evaluates its value before the
+// object, but a compound one needs the field's old value first.
+func evalPropertyAssignment(node *ast.PropertyAssignment, env *object.Environment) object.Object {
+	var val object.Object
+	if node.Operator == "" {
+		if val = Eval(node.Value, env); isError(val) {
+			return val
+		}
+	}
+	obj := Eval(node.Property.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	if node.Operator != "" {
+		old := GetField(obj, node.Property.Property.Value)
+		if val = evalAssignedValue(node.Operator, old, node.Value, env); isError(val) {
+			return val
+		}
+	}
+	if err := SetField(obj, node.Property.Property.Value, val); err != nil {
+		return err
+	}
+	return NULL
+}
+
+// evalIndexAssignment sets an element, evaluating in the same order as a property
+func evalIndexAssignment(node *ast.IndexAssignment, env *object.Environment) object.Object {
+	var val object.Object
+	if node.Operator == "" {
+		if val = Eval(node.Value, env); isError(val) {
+			return val
+		}
+	}
+	left := Eval(node.Index.Left, env)
+	if isError(left) {
+		return left
+	}
+	index := Eval(node.Index.Index, env)
+	if isError(index) {
+		return index
+	}
+	if node.Operator != "" {
+		old := EvalIndexExpression(left, index)
+		if val = evalAssignedValue(node.Operator, old, node.Value, env); isError(val) {
+			return val
+		}
+	}
+	if err := SetIndex(left, index, val); err != nil {
+		return err
+	}
+	return NULL
+}
+
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
@@ -397,6 +616,9 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
+	if builtin, ok := env.Builtin(node.Value); ok {
+		return builtin
+	}
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
@@ -413,10 +635,14 @@ func evalTypedIdentifier(node *ast.TypedIdentifier, env *object.Environment) obj
 	return val
 }
 
-func evalIndexExpression(left object.Object, index object.Object) object.Object {
+func EvalIndexExpression(left object.Object, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -427,23 +653,84 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	idx := index.(*object.Integer).Value
 	max := int64(len(arrayObject.Elements) - 1)
 
-	if idx > max {
-		return newError("index out of bounds")
-	}
-
 	if idx < 0 {
 		idx = int64(len(arrayObject.Elements)) + idx
 	}
 
+	if idx > max || idx < 0 {
+		return newKindError(object.INDEX_ERROR, "index out of bounds")
+	}
+
 	return arrayObject.Elements[idx]
 }
 
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+
+	key, err := hashKey(hashObject, index)
+	if err != nil {
+		return err
+	}
+
+	pair, ok := hashObject.Pairs[key]
+	if !ok {
+		return newKindError(object.KEY_ERROR, "key not found: %s", index.Inspect())
+	}
+
+	return pair.Value
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	keys := []object.Object{}
+	values := []object.Object{}
+
+	for i, keyNode := range node.Keys {
+		key := Eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+		value := Eval(node.Values[i], env)
+		if isError(value) {
+			return value
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	return NewHash(keys, values)
+}
+
+// hashKey gives the key to look up index by in hash, checking it's of the hash's key type
+func hashKey(hash *object.Hash, index object.Object) (object.HashKey, *object.Error) {
+	hashable, ok := index.(object.Hashable)
+	if !ok {
+		return object.HashKey{}, newError("unusable as hashmap key: %s", index.Type())
+	}
+	if hash.KeyType != "" && typeName(index) != hash.KeyType {
+		return object.HashKey{}, newError("can't use %s as key of hashmap(%s)", typeName(index), hashTypeName(hash))
+	}
+	return hashable.HashKey(), nil
+}
+
+// hashTypeName gives the types of a hash as they're written in a hashmap(K, V) declaration
+func hashTypeName(hash *object.Hash) string {
+	return hash.KeyType + ", " + hash.ValueType
+}
+
+func splitHashType(types string) (string, string) {
+	parts := ast.SplitTypes(types)
+	if len(parts) != 2 {
+		return types, ""
+	}
+	return parts[0], parts[1]
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
 		return condition
 	}
-	if isTruthy(condition) {
+	if IsTruthy(condition) {
 		return Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
 		return Eval(ie.Alternative, env)
@@ -452,39 +739,264 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
-func isTruthy(obj object.Object) bool {
-	switch obj {
-	case NULL:
-		return false
-	case TRUE:
-		return true
-	case FALSE:
-		return false
-	default:
-		return false
+// IsTruthy reports whether a value counts as true. Booleans are compared on their
+// value, as a host embedding the language can make its own rather than using TRUE
+// and FALSE.
+func IsTruthy(obj object.Object) bool {
+	if b, ok := obj.(*object.Boolean); ok {
+		return b.Value
 	}
+	return false
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// applyFunction runs a function, checking what a user defined one gives against its
+// return type
+func applyFunction(fn object.Object, args []object.Object, ctx *object.Context) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
 		extendedEnv := extendFunctionEnv(fn, args)
 		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if evaluated != nil && (evaluated.Type() == object.BREAK_OBJ || evaluated.Type() == object.CONTINUE_OBJ) {
+			return newError("%s outside of a loop in function %s", evaluated.Inspect(), fn.Name.String())
+		}
+		if isError(evaluated) {
+			return evaluated
+		}
+		// the result is checked while the function is still on the call stack, so a
+		// wrong one is raised from where it returned
+		pos := fn.Pos
+		if returnValue, ok := evaluated.(*object.ReturnValue); ok {
+			pos = returnValue.Pos
+		}
+		result := CheckReturnType(fn.Name.String(), fn.ReturnType, unwrapReturnValue(evaluated))
+		if err, ok := result.(*object.Error); ok {
+			err.Pos = pos
+		}
+		return result
 	case *object.Builtin:
-		return fn.Fn(args...)
+		return fn.Fn(ctx, args...)
 	case *object.Class:
-		env := object.NewEnvironment()
-		for paramIdx, x := range fn.Parameters {
-			env.Set(x.Value, args[paramIdx])
-		}
-		Eval(fn.Body, env)
-		return &object.Class{Name: fn.Name, Body: fn.Body, Parameters: fn.Parameters, Env: env}
+		return construct(fn, args, nil)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
+// construct builds the layer of an instance belonging to a class, after the layers of
+// its parents. Each layer's environment is enclosed by its parent's, so a subclass
+// sees the fields and methods it inherits.
+func construct(class *object.Class, args []object.Object, this *object.Instance) object.Object {
+	if err := CheckArity(class.Name.Value, len(args), len(class.Parameters)); err != nil {
+		return err
+	}
+
+	layer := &object.Instance{Class: class, This: this}
+	if this == nil {
+		layer.This = layer
+	}
+
+	outer := class.Env
+	if class.Parent != nil {
+		parentArgs := args
+		if class.ParentArguments != nil {
+			env := object.NewEnclosedEnvironment(class.Env)
+			for paramIdx, x := range class.Parameters {
+				env.Set(x.Value, args[paramIdx])
+			}
+			parentArgs = evalExpressions(class.ParentArguments, env)
+			if len(parentArgs) == 1 && isError(parentArgs[0]) {
+				return parentArgs[0]
+			}
+		}
+		// the parent's constructor is called by the class, as it is on the vm
+		calls := class.Env.Calls()
+		if err := enter(class.Env, class.Parent.Name.Value, class.Name.Pos()); err != nil {
+			return err
+		}
+		super := construct(class.Parent, parentArgs, layer.This)
+		calls.Capture(super)
+		calls.Pop()
+		if isError(super) {
+			return super
+		}
+		layer.Super = super.(*object.Instance)
+		outer = layer.Super.Env
+	}
+
+	layer.Env = object.NewEnclosedEnvironment(outer)
+	layer.Env.Set("this", layer.This)
+	if layer.Super != nil {
+		layer.Env.Set("super", layer.Super)
+	}
+	for paramIdx, x := range class.Parameters {
+		layer.Env.Set(x.Value, args[paramIdx])
+	}
+	if result := Eval(class.Body, layer.Env); isError(result) {
+		return result
+	}
+	return layer
+}
+
+// callFunction evaluates the arguments to a call and applies the function to them
+func callFunction(function object.Object, arguments []ast.Expression, env *object.Environment, site token.Position) object.Object {
+	args := evalExpressions(arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	return call(function, args, env, site)
+}
+
+// CallFunction calls a function, class or builtin with arguments that have already
+// been evaluated, as the program's host does
+func CallFunction(function object.Object, args []object.Object, env *object.Environment) object.Object {
+	return call(function, args, env, token.Position{})
+}
+
+// call applies a function to its arguments. A function or constructor runs as a call
+// on the program's call stack, made from site.
+func call(function object.Object, args []object.Object, env *object.Environment, site token.Position) object.Object {
+	switch fn := function.(type) {
+	case *object.Function:
+		if err := CheckArity(fn.Name.String(), len(args), len(fn.Parameters)); err != nil {
+			return err
+		}
+		calls := env.Calls()
+		if err := enter(env, fn.Name.String(), site); err != nil {
+			return err
+		}
+		result := applyFunction(function, args, env.Context())
+		calls.Capture(result)
+		calls.Pop()
+		return result
+	case *object.Class:
+		if err := CheckArity(fn.Name.Value, len(args), len(fn.Parameters)); err != nil {
+			return err
+		}
+		calls := env.Calls()
+		if err := enter(env, fn.Name.Value, site); err != nil {
+			return err
+		}
+		result := applyFunction(function, args, env.Context())
+		calls.Capture(result)
+		calls.Pop()
+		return result
+	default:
+		// a builtin like map calls back into the program, from where it was called
+		ctx := env.Context().WithCall(func(fn object.Object, args ...object.Object) object.Object {
+			return call(fn, args, env, site)
+		})
+		return applyFunction(function, args, ctx)
+	}
+}
+
+// enter pushes a call onto the program's call stack, unless it would go deeper than
+// the program is allowed to. Without a limit, a function recursing forever would
+// overflow the Go stack, which can't be recovered from.
+func enter(env *object.Environment, function string, site token.Position) *object.Error {
+	calls := env.Calls()
+	if max := env.Limits().MaxDepth; max > 0 && len(calls.Calls) >= max {
+		return RecursionError(function)
+	}
+	calls.Push(function, site)
+	return nil
+}
+
+// evalTry runs a try statement. Like other statements it has no value of its own,
+// only passing on a return, break or error. The finally block's result replaces the
+// statement's only if it's one of those.
+func evalTry(node *ast.TryStatement, env *object.Environment) object.Object {
+	result := Eval(node.Body, env)
+	if err, ok := result.(*object.Error); ok {
+		env.Calls().Capture(err)
+		for _, clause := range node.Catches {
+			if CatchMatches(clause, err) {
+				// the error is only named inside its clause
+				catchEnv := object.NewEnclosedEnvironment(env)
+				catchEnv.Set(clause.Name.Value, &object.ErrorValue{Error: err})
+				result = Eval(clause.Body, catchEnv)
+				break
+			}
+		}
+	}
+	if node.Finally != nil {
+		finally := Eval(node.Finally, env)
+		if finally != nil {
+			switch finally.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+				return finally
+			}
+		}
+	}
+	if result != nil {
+		switch result.Type() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+			return result
+		}
+	}
+	return NULL
+}
+
+// evalImport binds the module an import refers to, running it first if it hasn't been
+// imported before
+func evalImport(node *ast.ImportStatement, env *object.Environment) object.Object {
+	str, ok := node.Value.(*ast.StringLiteral)
+	if !ok {
+		return newError("invalid import path")
+	}
+	file, ok := module.Resolve(str.Value, node.Pos().Filename)
+	if !ok {
+		return newError("couldn't import file '%s'", str.Value)
+	}
+	name, err := ImportName(node, file)
+	if err != nil {
+		return err
+	}
+
+	modules := env.Modules()
+	mod, ok := modules.Loaded[file]
+	if !ok {
+		if cycle, ok := module.Stack(modules.Loading).Cycle(file, node.Pos().Filename); ok {
+			return newError("import cycle: %s", cycle)
+		}
+		program, err := module.Parse(file)
+		if err != nil {
+			return newError("something went wrong while importing '%s': %s", str.Value, err)
+		}
+
+		mod = &object.Module{Name: module.Name(file), File: file, Env: object.NewModuleEnvironment(env)}
+		loading := modules.Loading
+		modules.Loading = module.Stack(loading).Enter(file, node.Pos().Filename)
+		calls := env.Calls()
+		if err := enter(env, mod.Name, node.Pos()); err != nil {
+			modules.Loading = loading
+			return err
+		}
+		result := Eval(program, mod.Env)
+		calls.Capture(result)
+		calls.Pop()
+		modules.Loading = loading
+		if isError(result) {
+			return result
+		}
+		modules.Loaded[file] = mod
+	}
+
+	env.Set(name, mod)
+	return NULL
+}
+
+func evalMethodCall(node *ast.CallExpression, env *object.Environment) object.Object {
+	obj := Eval(node.Outer, env)
+	if isError(obj) {
+		return obj
+	}
+	method := GetMethod(obj, node.Function.TokenLiteral())
+	if isError(method) {
+		return method
+	}
+	return callFunction(method, node.Arguments, env, node.Pos())
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
@@ -504,7 +1016,11 @@ func unwrapReturnValue(obj object.Object) object.Object {
 }
 
 func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+	return newKindError(object.GENERIC_ERROR, format, a...)
+}
+
+func newKindError(kind string, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Kind: kind}
 }
 
 func isError(obj object.Object) bool {
@@ -514,6 +1030,10 @@ func isError(obj object.Object) bool {
 	return false
 }
 
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ || obj.Type() == object.BIGINT_OBJ
+}
+
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
 		return TRUE
@@ -4,6 +4,7 @@ import (
 	"github.com/OisinA/Azula/lexer"
 	"github.com/OisinA/Azula/object"
 	"github.com/OisinA/Azula/parser"
+	"github.com/OisinA/Azula/testsuite"
 	"testing"
 )
 
@@ -362,3 +363,285 @@ func TestClassCalls(t *testing.T) {
 		t.Fatalf("object is not Integer. got=%T (%+v)", evaluated, evaluated)
 	}
 }
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"foobar", 1, 1},
+		{"int x = 5;\nx - true;", 2, 3},
+		{"func f(int a): int {\n\treturn a + y;\n}\nf(1);", 2, 13},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Pos.Line != tt.expectedLine || errObj.Pos.Column != tt.expectedColumn {
+			t.Errorf("wrong error position. expected=%d:%d, got=%d:%d", tt.expectedLine, tt.expectedColumn, errObj.Pos.Line, errObj.Pos.Column)
+		}
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"-2.5", -2.5},
+		{"1.5 + 2.25", 3.75},
+		{"10.0 / 4.0", 2.5},
+		{"0.5 * 3.0", 1.5},
+		{"float x = 1.2; x - 0.2;", 1.2 - 0.2},
+		{"to_float(7) / 2.0", 3.5},
+		{`to_float("2.5")`, 2.5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestFloatConversions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"to_int(3.99)", 3},
+		{"to_int(-3.99)", -3},
+		{"1.5 < 2.5", true},
+		{"2.0 == 2.0", true},
+		{`"total: " + 2.5`, "total: 2.5"},
+		{`"" + 2.0`, "2.0"},
+		{"1 + 1.0", "type mismatch: INTEGER + FLOAT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `hashmap(string, int) ages = {"one": 1, "two": 1 + 1, "three": 6 / 2};
+	ages;`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if result.KeyType != "string" || result.ValueType != "int" {
+		t.Errorf("hash has wrong types. got=hashmap(%s, %s)", result.KeyType, result.ValueType)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("hash has wrong number of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+
+	if result.Inspect() != "{one: 1, two: 2, three: 3}" {
+		t.Errorf("hash inspected wrong. got=%q", result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`hashmap(int, string) names = {1242: "Oisin", 1284: "John"}; len(names[1284]);`, 4},
+		{`hashmap(bool, int) m = {true: 5}; m[true];`, 5},
+		{`hashmap(int, int) m = {}; len(m);`, 0},
+		{`{"foo": 5}["bar"]`, "key not found: bar"},
+		{`{"foo": 5}[1]`, "can't use int as key of hashmap(string, int)"},
+		{`{[1]: 5}`, "unusable as hashmap key: ARRAY"},
+		{`{1: 5, "two": 6}`, "trying to add string: int to hashmap(int, int)"},
+		{`hashmap(string, int) m = {1: 5};`, "trying to assign hashmap(int, int) to hashmap(string, int): m"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"a": 1, "b": 2})`, "[a, b]"},
+		{`values({"a": 1, "b": 2})`, "[1, 2]"},
+		{`has_key({"a": 1}, "a")`, "true"},
+		{`has_key({"a": 1}, "b")`, "false"},
+		{`delete({"a": 1, "b": 2}, "a")`, "{b: 2}"},
+		{`hashmap(string, int) m = {"a": 1}; hashmap(string, int) n = delete(m, "a"); m;`, "{a: 1}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestWhileLoopExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"int i = 0; while(i < 5) { i = i + 1; } i;", 5},
+		{"int i = 0; while(true) { i = i + 1; if(i > 3) { break; } } i;", 4},
+		{"int i = 0; int odd = 0; while(i < 10) { i = i + 1; if(i / 2 * 2 == i) { continue; } odd = odd + 1; } odd;", 5},
+		{"int total = 0; for(x in range(10)) { if(x > 4) { break; } total = total + x; } total;", 10},
+		{"int total = 0; for(x in range(5)) { if(x == 2) { continue; } total = total + x; } total;", 8},
+		{"func find(): int { for(x in range(10)) { if(x == 3) { return x; } } return -1; } find();", 3},
+		{"func find(): int { int i = 0; while(true) { if(i == 7) { return i; } i = i + 1; } return -1; } find();", 7},
+		{"int n = 0; for(x in range(3)) { for(y in range(3)) { if(y == 1) { break; } n = n + 1; } } n;", 3},
+		{"while(false) { 1; }", nil},
+		{"break;", "break outside of a loop"},
+		{"func f(): int { continue; } f();", "continue outside of a loop in function f"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxDepth int
+		maxSteps int
+		expected string
+	}{
+		{"func f(int n): int { return f(n + 1); } f(0);", 10, 0, "maximum recursion depth exceeded in f"},
+		{"func f(int n): int { if(n == 0) { return 0; } return f(n - 1); } f(10);", 11, 0, ""},
+		{"func f(int n): int { if(n == 0) { return 0; } return f(n - 1); } f(10);", 10, 0, "maximum recursion depth exceeded in f"},
+		{"func f(int n): int { if(n == 0) { return 0; } return f(n - 1); } f(5000);", 0, 0, ""},
+		{"while(true) { 1; }", 0, 1000, "maximum number of steps exceeded (1000)"},
+		{"while(true) { try { 1; } catch (e) { 2; } }", 0, 1000, "maximum number of steps exceeded (1000)"},
+		{"int i = 0; while(i < 10) { i = i + 1; }", 0, 1000, ""},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.Limits().MaxDepth = tt.maxDepth
+		env.Limits().MaxSteps = tt.maxSteps
+		evaluated := Eval(program, env)
+
+		errObj, ok := evaluated.(*object.Error)
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("%q: unexpected error %q", tt.input, errObj.Message)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestBackendSuite(t *testing.T) {
+	testsuite.Run(t, testEval)
+}
+
+func TestBackendIO(t *testing.T) {
+	testsuite.RunIO(t, func(input string, ctx *object.Context) object.Object {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.SetContext(ctx)
+		return Eval(program, env)
+	})
+}
@@ -0,0 +1,140 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/OisinA/Azula/object"
+)
+
+// Arithmetic on ints raises an OverflowError rather than wrapping around, as a result
+// that doesn't fit in an int is wrong. A program that needs larger numbers uses bigints.
+
+// AddIntegers gives left + right, or an OverflowError if it doesn't fit in an int
+func AddIntegers(left int64, right int64) object.Object {
+	result := left + right
+	if (left >= 0) == (right >= 0) && (result >= 0) != (left >= 0) {
+		return overflowError(left, "+", right)
+	}
+	return &object.Integer{Value: result}
+}
+
+// SubtractIntegers gives left - right, or an OverflowError if it doesn't fit in an int
+func SubtractIntegers(left int64, right int64) object.Object {
+	result := left - right
+	if (left >= 0) != (right >= 0) && (result >= 0) != (left >= 0) {
+		return overflowError(left, "-", right)
+	}
+	return &object.Integer{Value: result}
+}
+
+// MultiplyIntegers gives left * right, or an OverflowError if it doesn't fit in an int
+func MultiplyIntegers(left int64, right int64) object.Object {
+	if left == 0 || right == 0 {
+		return &object.Integer{Value: 0}
+	}
+	result := left * right
+	if result/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+		return overflowError(left, "*", right)
+	}
+	return &object.Integer{Value: result}
+}
+
+// DivideIntegers gives left / right or left % right, or an error if right is zero
+func DivideIntegers(operator string, left int64, right int64) object.Object {
+	if right == 0 {
+		return zeroDivisionError(operator)
+	}
+	if operator == "%" {
+		return &object.Integer{Value: left % right}
+	}
+	if left == math.MinInt64 && right == -1 {
+		return overflowError(left, "/", right)
+	}
+	return &object.Integer{Value: left / right}
+}
+
+// ShiftIntegers gives left << right or left >> right, or an OverflowError if bits
+// shifted left are lost off the end of an int
+func ShiftIntegers(operator string, left int64, right int64) object.Object {
+	if right < 0 {
+		return newError("negative shift count: %d", right)
+	}
+	if operator == ">>" {
+		return &object.Integer{Value: left >> uint64(right)}
+	}
+	result := left << uint64(right)
+	if left != 0 && (right >= 64 || result>>uint64(right) != left) {
+		return overflowError(left, "<<", right)
+	}
+	return &object.Integer{Value: result}
+}
+
+func overflowError(left int64, operator string, right int64) *object.Error {
+	return newKindError(object.OVERFLOW_ERROR, "integer overflow: %d %s %d", left, operator, right)
+}
+
+func zeroDivisionError(operator string) *object.Error {
+	if operator == "%" {
+		return newKindError(object.ZERO_DIVISION_ERROR, "modulo by zero")
+	}
+	return newKindError(object.ZERO_DIVISION_ERROR, "division by zero")
+}
+
+func evalBigIntInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := left.(*object.BigInt).Value
+	rightVal := right.(*object.BigInt).Value
+	result := new(big.Int)
+
+	switch operator {
+	case "+":
+		result.Add(leftVal, rightVal)
+	case "-":
+		result.Sub(leftVal, rightVal)
+	case "*":
+		result.Mul(leftVal, rightVal)
+	case "/", "%":
+		if rightVal.Sign() == 0 {
+			return zeroDivisionError(operator)
+		}
+		// these truncate like the operators on ints, rather than rounding down
+		if operator == "/" {
+			result.Quo(leftVal, rightVal)
+		} else {
+			result.Rem(leftVal, rightVal)
+		}
+	case "&":
+		result.And(leftVal, rightVal)
+	case "|":
+		result.Or(leftVal, rightVal)
+	case "^":
+		result.Xor(leftVal, rightVal)
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return newError("negative shift count: %s", rightVal)
+		}
+		if !rightVal.IsUint64() || rightVal.Uint64() > math.MaxUint32 {
+			return newError("shift count too large: %s", rightVal)
+		}
+		if operator == "<<" {
+			result.Lsh(leftVal, uint(rightVal.Uint64()))
+		} else {
+			result.Rsh(leftVal, uint(rightVal.Uint64()))
+		}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	return &object.BigInt{Value: result}
+}
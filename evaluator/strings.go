@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/OisinA/Azula/object"
+)
+
+// Strings are indexed, sliced and measured by character rather than by byte, so "é"[0]
+// is "é" and len("é") is 1.
+
+// maxStringLength is the longest string, in bytes, that repeat or padding will make
+const maxStringLength = 1 << 30
+
+// Interpolate joins the parts of an interpolated string into one, writing those that
+// aren't strings as they are printed
+func Interpolate(parts []object.Object) object.Object {
+	var out strings.Builder
+	for _, part := range parts {
+		if str, ok := part.(*object.String); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString(part.Inspect())
+		}
+	}
+	return &object.String{Value: out.String()}
+}
+
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+
+	if idx < 0 {
+		idx = int64(len(chars)) + idx
+	}
+
+	if idx >= int64(len(chars)) || idx < 0 {
+		return newKindError(object.INDEX_ERROR, "index out of bounds")
+	}
+
+	return &object.String{Value: string(chars[idx])}
+}
+
+// EvalSliceExpression gives the part of an array or string from start up to end. Like
+// indexes, the bounds count back from the end if negative, and either can be NULL to
+// slice from the start or to the end. Unlike indexes, bounds past the start or end are
+// taken as the start or end.
+func EvalSliceExpression(left object.Object, start object.Object, end object.Object) object.Object {
+	var length int
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		length = utf8.RuneCountInString(left.Value)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	bounds := []int64{0, int64(length)}
+	for i, bound := range []object.Object{start, end} {
+		switch bound := bound.(type) {
+		case *object.Null:
+		case *object.Integer:
+			bounds[i] = bound.Value
+			if bounds[i] < 0 {
+				bounds[i] += int64(length)
+			}
+			// bounds past either end are taken as the end they're past
+			if bounds[i] < 0 {
+				bounds[i] = 0
+			} else if bounds[i] > int64(length) {
+				bounds[i] = int64(length)
+			}
+		default:
+			return newKindError(object.TYPE_ERROR, "slice bounds must be int, got %s", bound.Type())
+		}
+	}
+	from, to := bounds[0], bounds[1]
+	if from > to {
+		return newKindError(object.INDEX_ERROR, "slice bounds out of range")
+	}
+
+	if array, ok := left.(*object.Array); ok {
+		elements := append([]object.Object{}, array.Elements[from:to]...)
+		return &object.Array{ElementType: array.ElementType, Elements: elements}
+	}
+	return &object.String{Value: string([]rune(left.(*object.String).Value)[from:to])}
+}
+
+// runeIndex gives the index of the first instance of substr in s, or -1 if there isn't
+// one. The index counts characters, like indexing a string does.
+func runeIndex(s string, substr string) int64 {
+	i := strings.Index(s, substr)
+	if i < 0 {
+		return -1
+	}
+	return int64(utf8.RuneCountInString(s[:i]))
+}
+
+// stringArguments gives the values of the arguments to a builtin that only takes strings
+func stringArguments(name string, args []object.Object) ([]string, *object.Error) {
+	strs := []string{}
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			if len(args) == 1 {
+				return nil, newKindError(object.TYPE_ERROR, "argument to '%s' must be string, got %s", name, arg.Type())
+			}
+			return nil, newKindError(object.TYPE_ERROR, "argument %d to '%s' must be string, got %s", i+1, name, arg.Type())
+		}
+		strs = append(strs, str.Value)
+	}
+	return strs, nil
+}
+
+// stringBuiltin makes a builtin like upper, which changes one string into another
+func stringBuiltin(name string, fn func(s string) string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity(name, len(args), 1); err != nil {
+				return err
+			}
+			strs, err := stringArguments(name, args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: fn(strs[0])}
+		},
+	}
+}
+
+// stringTest makes a builtin like contains, which tells whether a string has another in it
+func stringTest(name string, fn func(s string, sub string) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := CheckArity(name, len(args), 2); err != nil {
+				return err
+			}
+			strs, err := stringArguments(name, args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(fn(strs[0], strs[1]))
+		},
+	}
+}
+
+// padBuiltin makes pad_left or pad_right, which add a character, or spaces if none is
+// given, to one side of a string until it is as many characters wide as asked
+func padBuiltin(name string, left bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkArityBetween(name, len(args), 2, 3); err != nil {
+				return err
+			}
+			strs, err := stringArguments(name, append(args[:1:1], args[2:]...))
+			if err != nil {
+				return err
+			}
+			width, ok := args[1].(*object.Integer)
+			if !ok {
+				return newKindError(object.TYPE_ERROR, "second argument to '%s' must be int, got %s", name, args[1].Type())
+			}
+			pad := " "
+			if len(strs) == 2 {
+				pad = strs[1]
+			}
+			if utf8.RuneCountInString(pad) != 1 {
+				return newKindError(object.VALUE_ERROR, "padding given to '%s' must be one character, got %d characters", name, utf8.RuneCountInString(pad))
+			}
+
+			missing := width.Value - int64(utf8.RuneCountInString(strs[0]))
+			if missing <= 0 {
+				return args[0]
+			}
+			if missing > maxStringLength/int64(len(pad)) {
+				return newKindError(object.OVERFLOW_ERROR, "padded string is too long")
+			}
+			padding := strings.Repeat(pad, int(missing))
+			if left {
+				return &object.String{Value: padding + strs[0]}
+			}
+			return &object.String{Value: strs[0] + padding}
+		},
+	}
+}
@@ -1,6 +1,12 @@
 package lexer
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/OisinA/Azula/token"
 )
 
@@ -8,25 +14,70 @@ type Lexer struct {
 	input        string
 	position     int  // current position input (current char)
 	readPosition int  // current read position in input (after currrent char)
-	ch           byte // current character under examination
+	ch           rune // current character under examination
+	filename     string
+	line         int      // line of the current character
+	column       int      // column of the current character
+	doc          []string // lines of the comments just read, kept for the next token
+	docEnd       int      // line the last of those comments ended on
+	tokenLine    int      // line the last token started on
+	// interpolation is the innermost ${ the lexer is in, if any
+	interpolation *interpolation
+}
+
+// interpolation tracks the braces opened in the expression of a ${ in a string, so the }
+// ending it can be told apart from those closing them. It's never changed once made,
+// so a copy of the lexer can read ahead without affecting the original.
+type interpolation struct {
+	braces int
+	outer  *interpolation
 }
 
 // New gives a Lexer using the given input
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFilename(input, "")
+}
+
+// NewWithFilename gives a Lexer whose token positions refer to the given file
+func NewWithFilename(input string, filename string) *Lexer {
+	l := &Lexer{input: input, filename: filename, line: 1}
 	l.readChar()
 	return l
 }
 
-// readChar gives us the next charachter and advances our position in the input string
+// invalidChar stands in for a byte of the input that isn't part of valid UTF-8
+const invalidChar rune = -1
+
+// readChar gives us the next charachter and advances our position in the input string.
+// Characters are decoded from UTF-8, and columns count characters rather than bytes.
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // checks if we reached end of input, if so set to NUL
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = decodeChar(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
+	l.column++
+}
+
+// decodeChar gives the first character of s and how many bytes it takes up
+func decodeChar(s string) (rune, int) {
+	ch, width := utf8.DecodeRuneInString(s)
+	if ch == utf8.RuneError && width == 1 {
+		return invalidChar, 1
+	}
+	return ch, width
+}
+
+// pos gives the position of the current character
+func (l *Lexer) pos() token.Position {
+	return token.Position{Filename: l.filename, Line: l.line, Column: l.column}
 }
 
 // NextToken looks at the next character under examination and returns a token depending on which character it is.
@@ -34,7 +85,13 @@ func (l *Lexer) readChar() {
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
-	l.skipWhitespace()
+	if bad := l.skipWhitespace(); bad != nil {
+		return *bad
+	}
+
+	pos := l.pos()
+	doc := l.takeDoc(pos.Line)
+	l.tokenLine = pos.Line
 
 	switch l.ch {
 	case '=':
@@ -47,7 +104,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.readOperator(token.MINUS)
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -58,13 +115,23 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		tok = l.readOperator(token.SLASH)
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		tok = l.readOperator(token.ASTERISK)
+	case '%':
+		tok = l.readOperator(token.PERCENT)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		tok = l.readOperator(token.LT)
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		tok = l.readOperator(token.GT)
+	case '&':
+		tok = l.readOperator(token.BIT_AND)
+	case '|':
+		tok = l.readOperator(token.BIT_OR)
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case '(':
@@ -74,16 +141,30 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.readOperator(token.PLUS)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
+		if in := l.interpolation; in != nil {
+			l.interpolation = &interpolation{braces: in.braces + 1, outer: in.outer}
+		}
 	case '}':
+		in := l.interpolation
+		if in != nil && in.braces == 0 {
+			// the end of an interpolation, so what follows is more of the string
+			l.interpolation = in.outer
+			tok = l.readString(token.STRING_MIDDLE, token.STRING_TAIL)
+			break
+		}
 		tok = newToken(token.RBRACE, l.ch)
+		if in != nil {
+			l.interpolation = &interpolation{braces: in.braces - 1, outer: in.outer}
+		}
 	case ':':
 		tok = newToken(token.RETURN_TYPE, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readString(token.STRING_HEAD, token.STRING)
+	case '`':
+		tok = l.readRawString()
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -93,78 +174,390 @@ func (l *Lexer) NextToken() token.Token {
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
+	case invalidChar:
+		tok = token.Token{Type: token.INVALID, Literal: "invalid UTF-8 encoding"}
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
+			tok.Doc = doc
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Pos = pos
+			tok.Doc = doc
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 
+	// a string with something wrong in it is reported where that is
+	if !tok.Pos.IsValid() {
+		tok.Pos = pos
+	}
+	tok.Doc = doc
 	l.readChar()
 	return tok
 }
 
+// longOperators are the operators of two characters, by their first and second
+var longOperators = map[rune]map[rune]token.TokenType{
+	'+': {'=': token.PLUS_ASSIGN, '+': token.INCREMENT},
+	'-': {'=': token.MINUS_ASSIGN, '-': token.DECREMENT},
+	'*': {'=': token.ASTERISK_ASSIGN},
+	'/': {'=': token.SLASH_ASSIGN},
+	'%': {'=': token.PERCENT_ASSIGN},
+	'<': {'=': token.LT_EQ, '<': token.SHIFT_LEFT},
+	'>': {'=': token.GT_EQ, '>': token.SHIFT_RIGHT},
+	'&': {'&': token.AND},
+	'|': {'|': token.OR},
+}
+
+// readOperator reads an operator of one character, or of two if the next character
+// makes one of the longer operators starting with it
+func (l *Lexer) readOperator(single token.TokenType) token.Token {
+	if tokType, ok := longOperators[l.ch][l.peekChar()]; ok {
+		ch := l.ch
+		l.readChar()
+		return token.Token{Type: tokType, Literal: string(ch) + string(l.ch)}
+	}
+	return newToken(single, l.ch)
+}
+
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isIdentifierPart(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-// skipWhitespace ignores all whitespace
-func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+// skipWhitespace ignores all whitespace and comments. A comment is either a line
+// comment, from # or // to the end of the line, or a block comment between #[ and ]#,
+// which can be nested. It stops after a comment that isn't valid UTF-8, giving the
+// INVALID token for it.
+func (l *Lexer) skipWhitespace() *token.Token {
+	for {
+		var bad *token.Token
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '#' && l.peekChar() == '[':
+			bad = l.readBlockComment()
+		case l.ch == '#' || l.ch == '/' && l.peekChar() == '/':
+			bad = l.readLineComment()
+		default:
+			return nil
+		}
+		if bad != nil {
+			return bad
+		}
+	}
+}
+
+// checkComment gives the INVALID token for the first character of a comment that
+// isn't valid UTF-8, if the comment has one and the current character is it
+func (l *Lexer) checkComment(bad *token.Token) *token.Token {
+	if l.ch == invalidChar && bad == nil {
+		return &token.Token{Type: token.INVALID, Literal: "invalid UTF-8 encoding", Pos: l.pos()}
+	}
+	return bad
+}
+
+// readLineComment reads a comment up to the end of the line, adding it to those kept
+// for the next token if it follows straight on from them
+func (l *Lexer) readLineComment() *token.Token {
+	line := l.line
+	position := l.position
+	var bad *token.Token
+	for l.ch != '\n' && l.ch != 0 {
+		bad = l.checkComment(bad)
+		l.readChar()
+	}
+	text := l.input[position:l.position]
+	if strings.HasPrefix(text, "#") {
+		text = text[1:]
+	} else {
+		text = text[2:]
+	}
+
+	// a comment after a token is about that token's line, not the next token
+	if line == l.tokenLine || l.docEnd != line-1 {
+		l.doc = nil
+	}
+	if line != l.tokenLine {
+		l.doc = append(l.doc, trimComment(text))
+	}
+	l.docEnd = line
+	return bad
+}
+
+// readBlockComment reads a block comment, including any nested in it. One left open
+// runs to the end of the input.
+func (l *Lexer) readBlockComment() *token.Token {
+	line := l.line
+	position := l.position
+	depth := 0
+	var bad *token.Token
+	for l.ch != 0 {
+		bad = l.checkComment(bad)
+		if l.ch == '#' && l.peekChar() == '[' {
+			depth++
+			l.readChar()
+		} else if l.ch == ']' && l.peekChar() == '#' {
+			depth--
+			l.readChar()
+			if depth == 0 {
+				l.readChar()
+				break
+			}
+		}
 		l.readChar()
 	}
+
+	text := strings.TrimPrefix(l.input[position:l.position], "#[")
+	text = strings.TrimSuffix(text, "]#")
+	l.doc = nil
+	if line != l.tokenLine {
+		for _, text := range dedent(strings.Split(strings.TrimSpace(text), "\n")) {
+			l.doc = append(l.doc, trimComment(text))
+		}
+	}
+	l.docEnd = l.line
+	return bad
 }
 
-func (l *Lexer) readString() string {
+// dedent removes the indentation all the lines of a block comment after the first have
+// in common, the first having had its own removed already
+func dedent(lines []string) []string {
+	indent, found := "", false
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = lineIndent, true
+		}
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return lines
+}
+
+// trimComment removes the space, and any * lining up a block comment, before the text
+// of a line of a comment
+func trimComment(line string) string {
+	line = strings.TrimRight(line, " \t\r")
+	trimmed := strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(trimmed, "* ") || trimmed == "*" {
+		return strings.TrimPrefix(trimmed[1:], " ")
+	}
+	return strings.TrimPrefix(line, " ")
+}
+
+// takeDoc gives the comments just read, if they end on the line before the token
+// starting on the given line, or on the same line. They are only kept for one token.
+func (l *Lexer) takeDoc(line int) string {
+	doc := l.doc
+	l.doc = nil
+	if doc == nil || l.docEnd < line-1 {
+		return ""
+	}
+	return strings.Join(doc, "\n")
+}
+
+// readString reads the text of a string up to its closing ", giving a token of type end,
+// or up to a ${, giving one of type interpolated. Escape sequences are replaced by the
+// characters they stand for.
+func (l *Lexer) readString(interpolated token.TokenType, end token.TokenType) token.Token {
+	var out strings.Builder
+	var bad *token.Token
+	for {
+		l.readChar()
+		switch {
+		case l.ch == '"':
+			if bad != nil {
+				return *bad
+			}
+			return token.Token{Type: end, Literal: out.String()}
+		case l.ch == '$' && l.peekChar() == '{':
+			l.readChar()
+			l.interpolation = &interpolation{outer: l.interpolation}
+			if bad != nil {
+				return *bad
+			}
+			return token.Token{Type: interpolated, Literal: out.String()}
+		case l.ch == '\\' && l.peekChar() != '\n' && l.peekChar() != 0:
+			pos := l.pos()
+			l.readChar()
+			if err := l.readEscape(&out); err != "" && bad == nil {
+				bad = &token.Token{Type: token.INVALID, Literal: err, Pos: pos}
+			}
+		case l.ch == invalidChar:
+			if bad == nil {
+				bad = &token.Token{Type: token.INVALID, Literal: "invalid UTF-8 encoding", Pos: l.pos()}
+			}
+		case l.ch == '\n' || l.ch == 0:
+			// only raw strings can span lines
+			return token.Token{Type: token.INVALID, Literal: "unterminated string literal"}
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// escapes are the characters written after a \ in a string, by the ones they stand for
+var escapes = map[rune]string{
+	'n':  "\n",
+	't':  "\t",
+	'r':  "\r",
+	'0':  "\x00",
+	'\\': "\\",
+	'"':  "\"",
+	'$':  "$",
+}
+
+// readEscape writes the character the escape sequence after a \ stands for, giving an
+// error if it isn't one. \u{...} is any character, by its code point in hex.
+func (l *Lexer) readEscape(out *strings.Builder) string {
+	if escaped, ok := escapes[l.ch]; ok {
+		out.WriteString(escaped)
+		return ""
+	}
+	if l.ch == invalidChar {
+		return "invalid UTF-8 encoding"
+	}
+	if l.ch != 'u' {
+		return fmt.Sprintf("unknown escape sequence \\%c", l.ch)
+	}
+	if l.peekChar() != '{' {
+		return "\\u must be followed by a code point in braces, as in \\u{e9}"
+	}
+	l.readChar()
+	position := l.position + 1
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	digits := l.input[position : l.position+1]
+	if l.peekChar() != '}' || digits == "" {
+		return "\\u must be followed by a code point in braces, as in \\u{e9}"
+	}
+	l.readChar()
+	code, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return fmt.Sprintf("invalid code point \\u{%s}", digits)
+	}
+	out.WriteRune(rune(code))
+	return ""
+}
+
+// UnterminatedRawString is the literal of the INVALID token given for a raw string
+// with no closing backtick
+const UnterminatedRawString = "unterminated raw string literal"
+
+// readRawString reads a string between backticks, which can span lines and has no
+// escape sequences or interpolation
+func (l *Lexer) readRawString() token.Token {
 	position := l.position + 1
+	var bad *token.Token
 	for {
 		l.readChar()
-		if l.ch == '"' {
-			break
+		if l.ch == '`' {
+			if bad != nil {
+				return *bad
+			}
+			return token.Token{Type: token.STRING, Literal: l.input[position:l.position]}
+		}
+		if l.ch == invalidChar && bad == nil {
+			bad = &token.Token{Type: token.INVALID, Literal: "invalid UTF-8 encoding", Pos: l.pos()}
 		}
 		if l.ch == 0 {
-			break
+			return token.Token{Type: token.INVALID, Literal: UnterminatedRawString}
 		}
 	}
-	return l.input[position:l.position]
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// isLetter reports whether a character can be part of an identifier, which can use the
+// letters of any language
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
-func (l *Lexer) readNumber() string {
+// isIdentifierPart reports whether a character can be part of an identifier after its
+// first, as in Unicode's UAX #31: digits, and the marks that combine with the letter
+// before them, like the accent of an é written as an e and U+0301
+func isIdentifierPart(ch rune) bool {
+	return unicode.In(ch, unicode.Nd, unicode.Mn, unicode.Mc)
+}
+
+// readNumber reads an integer, or a float if it has a fractional part or an exponent
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
+	tokType := token.TokenType(token.INT)
+	l.readDigits()
+
+	// a '.' not followed by a digit is an access, as in 1.method()
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if isDigit(next) || ((next == '+' || next == '-') && isDigit(l.peekCharAt(2))) {
+			tokType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+
+	return tokType, l.input[position:l.position]
+}
+
+func (l *Lexer) readDigits() {
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
 // peekChar returns the next character, but doesn't increment the position
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt returns the character n places after the current one, without moving
+func (l *Lexer) peekCharAt(n int) rune {
+	position := l.readPosition
+	for ; n > 1 && position < len(l.input); n-- {
+		_, width := decodeChar(l.input[position:])
+		position += width
+	}
+	if position >= len(l.input) {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+	ch, _ := decodeChar(l.input[position:])
+	return ch
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
@@ -207,3 +207,328 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `int x = 5;
+	print(x);`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"int", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"print", 2, 2},
+		{"(", 2, 7},
+		{"x", 2, 8},
+		{")", 2, 9},
+		{";", 2, 10},
+	}
+
+	l := NewWithFilename(input, "test.azl")
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d", i, tt.expectedLine, tt.expectedColumn, tok.Pos.Line, tok.Pos.Column)
+		}
+
+		if tok.Pos.Filename != "test.azl" {
+			t.Fatalf("tests[%d] - filename wrong. expected=%q, got=%q", i, "test.azl", tok.Pos.Filename)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `5 1.25 3e2 1.5e-3 2E+4 7.method 1e`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "5"},
+		{token.FLOAT, "1.25"},
+		{token.FLOAT, "3e2"},
+		{token.FLOAT, "1.5e-3"},
+		{token.FLOAT, "2E+4"},
+		{token.INT, "7"},
+		{token.ACCESS, "."},
+		{token.IDENT, "method"},
+		{token.INT, "1"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `# a comment
+int x = 5; // after x
+#[ a block
+   comment #[ nested ]# ]#
+x / 2;
+# unfinished`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.LET, "int", 2},
+		{token.IDENT, "x", 2},
+		{token.ASSIGN, "=", 2},
+		{token.INT, "5", 2},
+		{token.SEMICOLON, ";", 2},
+		{token.IDENT, "x", 5},
+		{token.SLASH, "/", 5},
+		{token.INT, "2", 5},
+		{token.SEMICOLON, ";", 5},
+		{token.EOF, "", 6},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		if tok.Pos.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line wrong. expected=%d, got=%d", i, tt.expectedLine, tok.Pos.Line)
+		}
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedDoc string
+	}{
+		{"# Adds one.\nfunc", "Adds one."},
+		{"// Adds one\n// to x.\nfunc", "Adds one\nto x."},
+		{"#[\n * Adds one\n * to x.\n ]#\nfunc", "Adds one\nto x."},
+		{"#[ Adds one. ]# func", "Adds one."},
+		{"# indented\n#   code\nfunc", "indented\n  code"},
+		// a blank line separates a comment from what follows
+		{"# Adds one.\n\nfunc", ""},
+		{"# Old.\n\n# New.\nfunc", "New."},
+		// a comment after a token is about that token's line
+		{"x; # note\nfunc", ""},
+		{"x; #[ note ]#\nfunc", ""},
+		{"func", ""},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		var tok token.Token
+		for tok = l.NextToken(); tok.Type != token.FUNCTION; tok = l.NextToken() {
+			if tok.Doc != "" {
+				t.Errorf("doc kept for %q in %q: %q", tok.Literal, tt.input, tok.Doc)
+			}
+		}
+		if tok.Doc != tt.expectedDoc {
+			t.Errorf("doc wrong for %q. expected=%q, got=%q", tt.input, tt.expectedDoc, tok.Doc)
+		}
+
+		// the doc is only kept for the one token
+		if next := l.NextToken(); next.Doc != "" {
+			t.Errorf("doc kept past func in %q: %q", tt.input, next.Doc)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	input := `a % b <= c >= d && e || f & g | h ^ i << j >> ~k < l > m
+	a += b -= c *= d /= e %= f++ g-- - +`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "c"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "d"},
+		{token.AND, "&&"},
+		{token.IDENT, "e"},
+		{token.OR, "||"},
+		{token.IDENT, "f"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "g"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "h"},
+		{token.BIT_XOR, "^"},
+		{token.IDENT, "i"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.IDENT, "j"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "k"},
+		{token.LT, "<"},
+		{token.IDENT, "l"},
+		{token.GT, ">"},
+		{token.IDENT, "m"},
+		{token.IDENT, "a"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.IDENT, "b"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.IDENT, "c"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.IDENT, "d"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.IDENT, "e"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.IDENT, "f"},
+		{token.INCREMENT, "++"},
+		{token.IDENT, "g"},
+		{token.DECREMENT, "--"},
+		{token.MINUS, "-"},
+		{token.PLUS, "+"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	input := `"a\tb\n\"c\"\\ \u{e9}\$" "x ${y + "${z}"} w ${ {1: 2} }!" ` + "`raw \\n ${y}\nline`" + ` "\q" "open
+	`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, "a\tb\n\"c\"\\ é$"},
+		{token.STRING_HEAD, "x "},
+		{token.IDENT, "y"},
+		{token.PLUS, "+"},
+		{token.STRING_HEAD, ""},
+		{token.IDENT, "z"},
+		{token.STRING_TAIL, ""},
+		{token.STRING_MIDDLE, " w "},
+		{token.LBRACE, "{"},
+		{token.INT, "1"},
+		{token.RETURN_TYPE, ":"},
+		{token.INT, "2"},
+		{token.RBRACE, "}"},
+		{token.STRING_TAIL, "!"},
+		{token.STRING, "raw \\n ${y}\nline"},
+		{token.INVALID, "unknown escape sequence \\q"},
+		{token.INVALID, "unterminated string literal"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestIdentifierCharacters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cafe\u0301 = 1", "cafe\u0301"},
+		{"x2y = 1", "x2y"},
+		{"_٣ = 1", "_٣"},
+		{"क्षत्रिय = 1", "क्षत्रिय"},
+	}
+
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != token.IDENT || tok.Literal != tt.expected {
+			t.Errorf("%q: wrong token. expected=IDENT %q, got=%s %q", tt.input, tt.expected, tok.Type, tok.Literal)
+		}
+	}
+
+	// a mark or a digit can't start an identifier
+	for _, input := range []string{"\u0301e", "2x"} {
+		if tok := New(input).NextToken(); tok.Type == token.IDENT {
+			t.Errorf("%q: expected no identifier. got=%q", input, tok.Literal)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "string café = \"日本\"; größe_2 € \xff \"a\xfeb\" `\xfe`\n# a\xffb\xff\n#[ \xfe ]# 1"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.LET, "string", 1},
+		{token.IDENT, "café", 8},
+		{token.ASSIGN, "=", 13},
+		{token.STRING, "日本", 15},
+		{token.SEMICOLON, ";", 19},
+		{token.IDENT, "größe_2", 21},
+		{token.ILLEGAL, "€", 29},
+		{token.INVALID, "invalid UTF-8 encoding", 31},
+		{token.INVALID, "invalid UTF-8 encoding", 35},
+		{token.INVALID, "invalid UTF-8 encoding", 40},
+		{token.INVALID, "invalid UTF-8 encoding", 4},
+		{token.INVALID, "invalid UTF-8 encoding", 4},
+		{token.INT, "1", 9},
+		{token.EOF, "", 10},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		if tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - column wrong. expected=%d, got=%d", i, tt.expectedColumn, tok.Pos.Column)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"github.com/OisinA/Azula/repl"
+	"github.com/OisinA/Azula/ast"
+	"flag"
 	"fmt"
 	"os"
 	"io/ioutil"
@@ -9,31 +11,49 @@ import (
 	"github.com/OisinA/Azula/parser"
 	"github.com/OisinA/Azula/evaluator"
 	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/token"
+	"github.com/OisinA/Azula/typecheck"
+	"github.com/OisinA/Azula/compiler"
+	"github.com/OisinA/Azula/vm"
 )
 
+var useVM = flag.Bool("vm", false, "run programs on the bytecode vm rather than the tree-walking evaluator")
+var maxDepth = flag.Int("max-depth", object.DEFAULT_MAX_DEPTH, "how many calls deep a program can go, 0 for no limit")
+var maxSteps = flag.Int("max-steps", 0, "how many steps a program can run for, 0 for no limit")
+
 func main() {
-	if len(os.Args[1:]) > 0 {
-		dat, err := ioutil.ReadFile(os.Args[1])
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		filename := flag.Arg(0)
+		dat, err := ioutil.ReadFile(filename)
 		if err != nil {
-			fmt.Println("error: couldn't find file " + os.Args[1])
-			return
+			fmt.Fprintln(os.Stderr, "error: couldn't find file "+filename)
+			os.Exit(1)
 		}
-		env := object.NewEnvironment()
-		l := lexer.New(string(dat))
+		l := lexer.NewWithFilename(string(dat), filename)
 		p := parser.New(l)
 
 		program := p.ParseProgram()
 
 		if len(p.Errors()) != 0 {
-			printParserErrors(p.Errors())
-			return
+			printParserErrors(string(dat), p.ErrorList())
+			os.Exit(1)
+		}
+
+		if errors := typecheck.Check(program); len(errors) != 0 {
+			printTypeErrors(string(dat), filename, errors)
+			os.Exit(1)
 		}
 
-		evaluated := evaluator.Eval(program, env)
-		evaluated, ok := evaluated.(*object.Error)
+		evaluated := run(program)
+		errObj, ok := evaluated.(*object.Error)
 
 		if ok {
-			fmt.Println(evaluated.Inspect())
+			fmt.Fprint(object.StdioContext().Err, repl.FormatTrace(errObj, func(pos token.Position) string {
+				return sourceOf(pos, filename, string(dat))
+			}))
+			os.Exit(1)
 		}
 	} else {
 		fmt.Printf("Azula V0.0\n")
@@ -41,9 +61,47 @@ func main() {
 	}
 }
 
-func printParserErrors(errors []string) {
-	fmt.Print("parser errors:\n")
-	for _, msg := range errors {
-		fmt.Print("\t"+msg+"\n")
+// run runs a program on whichever backend was picked, giving its result
+func run(program *ast.Program) object.Object {
+	if !*useVM {
+		env := object.NewEnvironment()
+		env.Limits().MaxDepth = *maxDepth
+		env.Limits().MaxSteps = *maxSteps
+		return evaluator.Eval(program, env)
+	}
+
+	comp := compiler.New()
+	if err := comp.CompileProgram(program); err != nil {
+		compileErr := err.(*compiler.Error)
+		return &object.Error{Message: compileErr.Message, Pos: compileErr.Pos}
+	}
+	machine := vm.New(comp.Bytecode())
+	machine.SetLimits(&object.Limits{MaxDepth: *maxDepth, MaxSteps: *maxSteps})
+	return machine.Run()
+}
+
+func printParserErrors(source string, errors []*parser.Error) {
+	fmt.Fprint(os.Stderr, "parser errors:\n")
+	for _, err := range errors {
+		fmt.Fprint(os.Stderr, repl.FormatError(source, err.Pos, err.Message))
+	}
+}
+
+func printTypeErrors(source string, filename string, errors []*typecheck.Error) {
+	fmt.Fprint(os.Stderr, "type errors:\n")
+	for _, err := range errors {
+		fmt.Fprint(os.Stderr, repl.FormatError(sourceOf(err.Pos, filename, source), err.Pos, err.Message))
+	}
+}
+
+// sourceOf gives the source of the file a position points into, which may be an imported file
+func sourceOf(pos token.Position, filename string, source string) string {
+	if pos.Filename == "" || pos.Filename == filename {
+		return source
+	}
+	dat, err := ioutil.ReadFile(pos.Filename)
+	if err != nil {
+		return ""
 	}
+	return string(dat)
 }
@@ -0,0 +1,144 @@
+// Package module finds the files imported by a program. The evaluator, the compiler
+// and the type checker each load modules themselves, but they all resolve import
+// paths and report import cycles the same way.
+package module
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/parser"
+)
+
+// Extension is added to import paths that don't already end in it
+const Extension = ".azl"
+
+// Resolve finds the file an import path refers to, for an import made by the file
+// from. Paths starting with ./ or ../ are relative to the importing file. Any other
+// relative path is looked for next to the importing file, then in each directory of
+// AZULA_PATH, then in the bundled stdlib directory.
+func Resolve(path string, from string) (string, bool) {
+	if !strings.HasSuffix(path, Extension) {
+		path += Extension
+	}
+	if filepath.IsAbs(path) {
+		return found(path)
+	}
+
+	dir := filepath.Dir(from)
+	if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		return found(filepath.Join(dir, path))
+	}
+
+	for _, d := range append([]string{dir}, SearchPath()...) {
+		if file, ok := found(filepath.Join(d, path)); ok {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// SearchPath gives the directories imports are looked for in: the ones listed in
+// AZULA_PATH, followed by the stdlib directory bundled next to the executable. An
+// executable built by go run or go install isn't next to the stdlib, so the stdlib
+// in the source it was built from comes last.
+func SearchPath() []string {
+	dirs := []string{}
+	for _, d := range filepath.SplitList(os.Getenv("AZULA_PATH")) {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Join(filepath.Dir(exe), "stdlib"))
+	}
+	if _, file, _, ok := runtime.Caller(0); ok {
+		// this file is in the module directory, next to stdlib
+		dirs = append(dirs, filepath.Join(filepath.Dir(filepath.Dir(file)), "stdlib"))
+	}
+	return dirs
+}
+
+func found(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	return abs, true
+}
+
+// Name gives the name a module is known by when it is imported without one, which
+// is the name of its file without the extension
+func Name(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), Extension)
+}
+
+// IsValidName reports whether a module's name can be used as an identifier
+func IsValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Exported reports whether a name defined by a module can be used by the files that
+// import it. Names starting with an underscore are private to the module.
+func Exported(name string) bool {
+	return !strings.HasPrefix(name, "_")
+}
+
+// Parse reads and parses the file a module is in
+func Parse(file string) (*ast.Program, error) {
+	dat, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.New(lexer.NewWithFilename(string(dat), file))
+	program := p.ParseProgram()
+	if len(p.ErrorList()) != 0 {
+		return nil, p.ErrorList()[0]
+	}
+	return program, nil
+}
+
+// Stack is the chain of modules being loaded, each imported by the one before it
+type Stack []string
+
+// Enter gives the stack once file, imported by the file from, starts loading. The
+// file a program starts in is never loaded as a module, but it begins the chain.
+func (s Stack) Enter(file string, from string) Stack {
+	if abs, err := filepath.Abs(from); len(s) == 0 && from != "" && err == nil {
+		s = Stack{abs}
+	}
+	return append(s[:len(s):len(s)], file)
+}
+
+// Cycle gives the chain of imports that leads back to file, if importing it from the
+// file from would import it again, as in a.azl -> b.azl -> a.azl
+func (s Stack) Cycle(file string, from string) (string, bool) {
+	chain := s.Enter(file, from)
+	for i, f := range chain[:len(chain)-1] {
+		if f == file {
+			names := []string{}
+			for _, f := range chain[i:] {
+				names = append(names, filepath.Base(f))
+			}
+			return strings.Join(names, " -> "), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+package module
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	root, err := ioutil.TempDir("", "azula")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := []string{"main.azl", "lib/util.azl", "lib/near.azl", "path/shared.azl", "path/near.azl"}
+	for _, f := range files {
+		file := filepath.Join(root, f)
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := ioutil.WriteFile(file, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Setenv("AZULA_PATH", filepath.Join(root, "path"))
+	defer os.Unsetenv("AZULA_PATH")
+
+	tests := []struct {
+		path     string
+		from     string
+		expected string
+	}{
+		{"./lib/util.azl", "main.azl", "lib/util.azl"},
+		{"lib/util", "main.azl", "lib/util.azl"},
+		{"../main", "lib/util.azl", "main.azl"},
+		{"shared", "main.azl", "path/shared.azl"},
+		{"near", "lib/util.azl", "lib/near.azl"},
+		{"near", "main.azl", "path/near.azl"},
+		{"./shared", "main.azl", ""},
+		{"missing", "main.azl", ""},
+	}
+
+	for _, tt := range tests {
+		file, ok := Resolve(tt.path, filepath.Join(root, tt.from))
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("%q from %s: expected no file. got=%s", tt.path, tt.from, file)
+			}
+			continue
+		}
+		if !ok || file != filepath.Join(root, tt.expected) {
+			t.Errorf("%q from %s: wrong file. got=%q, want=%q", tt.path, tt.from, file, filepath.Join(root, tt.expected))
+		}
+	}
+}
+
+func TestResolveStdlib(t *testing.T) {
+	// the test binary isn't next to the stdlib, so it's found from the source
+	file, ok := Resolve("String", "main.azl")
+	if !ok || filepath.Base(filepath.Dir(file)) != "stdlib" {
+		t.Errorf("expected String to be found in the stdlib. got=%q", file)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	tests := []struct {
+		stack    Stack
+		file     string
+		from     string
+		expected string
+	}{
+		{Stack{}, "/b.azl", "/a.azl", ""},
+		{Stack{"/a.azl", "/b.azl"}, "/a.azl", "/b.azl", "a.azl -> b.azl -> a.azl"},
+		{Stack{"/a.azl", "/b.azl"}, "/b.azl", "/b.azl", "b.azl -> b.azl"},
+		{Stack{}, "/a.azl", "/a.azl", "a.azl -> a.azl"},
+		{Stack{"/a.azl", "/b.azl"}, "/c.azl", "/b.azl", ""},
+	}
+
+	for _, tt := range tests {
+		cycle, ok := tt.stack.Cycle(tt.file, tt.from)
+		if ok != (tt.expected != "") || cycle != tt.expected {
+			t.Errorf("%v importing %s: wrong cycle. got=%q, want=%q", tt.stack, tt.file, cycle, tt.expected)
+		}
+	}
+}
+
+func TestExported(t *testing.T) {
+	if !Exported("upper") || Exported("_helper") {
+		t.Errorf("names starting with an underscore should be private, and only them")
+	}
+}
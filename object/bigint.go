@@ -0,0 +1,19 @@
+package object
+
+import (
+	"math/big"
+)
+
+// BigInt is an integer of any size, for numbers that don't fit in an int. Its Value is
+// never changed once made, so it can be shared like any other value.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+
+func (b *BigInt) Type() ObjectType {
+	return BIGINT_OBJ
+}
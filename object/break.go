@@ -0,0 +1,24 @@
+package object
+
+// Break is returned from a block when a break statement runs, and stops the enclosing loop
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+// Continue is returned from a block when a continue statement runs, and skips to the
+// next iteration of the enclosing loop
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
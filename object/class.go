@@ -6,11 +6,18 @@ import (
 	"strings"
 )
 
+// Class is the definition of a class. Calling it builds an Instance.
 type Class struct {
 	Name *ast.Identifier
 	Parameters []*ast.TypedIdentifier
 	Body *ast.BlockStatement
+	// Env is the environment the class was defined in
 	Env *Environment
+	Parent *Class
+	ParentArguments []ast.Expression
+	Interfaces []*Interface
+	// Constructor builds instances of the class when it is run by the vm
+	Constructor *Closure
 }
 
 func (c *Class) Type() ObjectType {
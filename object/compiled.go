@@ -0,0 +1,95 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/code"
+	"github.com/OisinA/Azula/token"
+)
+
+// CompiledFunction is a function lowered to bytecode by the compiler
+type CompiledFunction struct {
+	Name          string
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int
+	Parameters    []*ast.TypedIdentifier
+	ReturnType    *ast.Identifier
+	// Positions maps the offset of an instruction to where in the source it came from
+	Positions map[int]token.Position
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure is a compiled function along with the free variables it captured. Closures
+// created inside a class keep the instance they were created for as their Receiver.
+type Closure struct {
+	Fn       *CompiledFunction
+	Free     []*Cell
+	Receiver *Instance
+}
+
+// Cell is a variable captured by a closure, shared with the function that defines it
+// so either one sees the other's assignments. While that function runs, Ref points at
+// the variable's slot on the vm's stack. Once it returns, the cell is closed and Ref
+// points at the cell's own copy of the value.
+type Cell struct {
+	Ref    *Object
+	closed Object
+}
+
+// NewCell gives an open cell for the variable in a slot
+func NewCell(slot *Object) *Cell {
+	return &Cell{Ref: slot}
+}
+
+// ClosedCell gives a cell holding a value no function is still running with
+func ClosedCell(val Object) *Cell {
+	c := &Cell{closed: val}
+	c.Ref = &c.closed
+	return c
+}
+
+// Close copies the variable into the cell, before its slot is reused
+func (c *Cell) Close() {
+	c.closed = *c.Ref
+	c.Ref = &c.closed
+}
+
+func (c *Cell) Type() ObjectType {
+	return CELL_OBJ
+}
+
+func (c *Cell) Inspect() string {
+	return (*c.Ref).Inspect()
+}
+
+func (c *Closure) Type() ObjectType {
+	return CLOSURE_OBJ
+}
+
+func (c *Closure) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range c.Fn.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("func ")
+	out.WriteString(c.Fn.Name)
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
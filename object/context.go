@@ -0,0 +1,45 @@
+package object
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// Context is what a builtin is given of the program calling it: the streams it reads
+// its input from and writes its output and errors to, and a way to call the functions
+// it is given
+type Context struct {
+	In  *bufio.Reader
+	Out io.Writer
+	Err io.Writer
+	// Call calls a function, class or builtin in the program, as map does with the
+	// function it is given. The backend running the program sets it.
+	Call func(fn Object, args ...Object) Object
+}
+
+// WithCall gives a copy of the context whose builtins call functions with call
+func (c *Context) WithCall(call func(fn Object, args ...Object) Object) *Context {
+	ctx := *c
+	ctx.Call = call
+	return &ctx
+}
+
+// NewContext gives a context using the given streams. Input is buffered once for the
+// whole program, so nothing read ahead by one call to input is lost to the next.
+func NewContext(in io.Reader, out io.Writer, err io.Writer) *Context {
+	reader, ok := in.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+	return &Context{In: reader, Out: out, Err: err}
+}
+
+// stdio is shared by every program using the process's own streams, as they would
+// otherwise each buffer stdin separately
+var stdio = NewContext(os.Stdin, os.Stdout, os.Stderr)
+
+// StdioContext gives the context of a program using the process's standard streams
+func StdioContext() *Context {
+	return stdio
+}
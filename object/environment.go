@@ -1,20 +1,86 @@
 package object
 
+import (
+	"sort"
+)
+
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	return newEnvironment(nil, &program{
+		modules:  &Modules{Loaded: make(map[string]*Module)},
+		calls:    &CallStack{},
+		limits:   &Limits{MaxDepth: DEFAULT_MAX_DEPTH},
+		builtins: make(map[string]*Builtin),
+		context:  StdioContext(),
+	})
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
+	return newEnvironment(outer, outer.program)
+}
+
+// NewModuleEnvironment gives the environment a module is run in. It sees none of
+// the names of the environment importing it, but shares its loaded modules.
+func NewModuleEnvironment(importer *Environment) *Environment {
+	return newEnvironment(nil, importer.program)
+}
+
+func newEnvironment(outer *Environment, p *program) *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, outer: outer, program: p}
+}
 
-	return env
+// program is what every environment of a running program shares
+type program struct {
+	modules *Modules
+	calls   *CallStack
+	limits  *Limits
+	// builtins are the ones the program's host has added to the language's own
+	builtins map[string]*Builtin
+	context  *Context
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	program *program
+}
+
+// Modules gives the modules loaded by the program the environment belongs to
+func (e *Environment) Modules() *Modules {
+	return e.program.modules
+}
+
+// Calls gives the call stack of the program the environment belongs to
+func (e *Environment) Calls() *CallStack {
+	return e.program.calls
+}
+
+// Builtin gives a builtin the program's host has added
+func (e *Environment) Builtin(name string) (*Builtin, bool) {
+	builtin, ok := e.program.builtins[name]
+	return builtin, ok
+}
+
+// SetBuiltin adds a builtin to the program, which replaces one of the language's own
+// of the same name
+func (e *Environment) SetBuiltin(name string, builtin *Builtin) {
+	e.program.builtins[name] = builtin
+}
+
+// Context gives the context the program's builtins are called with
+func (e *Environment) Context() *Context {
+	return e.program.context
+}
+
+// SetContext changes the streams the program reads and writes
+func (e *Environment) SetContext(ctx *Context) {
+	e.program.context = ctx
+}
+
+// Limits gives the limits on the program the environment belongs to, which can be
+// changed before it runs
+func (e *Environment) Limits() *Limits {
+	return e.program.limits
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -25,6 +91,22 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// GetLocal looks up a name in this environment only, without looking in the outer ones
+func (e *Environment) GetLocal(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	return obj, ok
+}
+
+// Names gives the names defined in this environment, sorted
+func (e *Environment) Names() []string {
+	names := []string{}
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
@@ -1,7 +1,32 @@
 package object
 
+import (
+	"github.com/OisinA/Azula/token"
+)
+
+// Kinds of the errors raised by the interpreter. A program can throw errors of any kind.
+const (
+	GENERIC_ERROR       = "Error"
+	ARITY_ERROR         = "ArityError"
+	CONVERSION_ERROR    = "ConversionError"
+	INDEX_ERROR         = "IndexError"
+	KEY_ERROR           = "KeyError"
+	TYPE_ERROR          = "TypeError"
+	RECURSION_ERROR     = "RecursionError"
+	STEP_LIMIT_ERROR    = "StepLimitError"
+	OVERFLOW_ERROR      = "OverflowError"
+	ZERO_DIVISION_ERROR = "ZeroDivisionError"
+	VALUE_ERROR         = "ValueError"
+)
+
+// Error is raised when something goes wrong while a program runs, and unwinds it until
+// it is caught. Stack holds the calls the program was in when it was raised, and is
+// nil until they are known.
 type Error struct {
 	Message string
+	Kind    string
+	Pos     token.Position
+	Stack   []Call
 }
 
 func (e *Error) Type() ObjectType {
@@ -11,3 +36,27 @@ func (e *Error) Type() ObjectType {
 func (e *Error) Inspect() string {
 	return "ERROR: " + e.Message
 }
+
+// Copy gives an error that can be raised again without changing this one
+func (e *Error) Copy() *Error {
+	var stack []Call
+	if e.Stack != nil {
+		stack = make([]Call, len(e.Stack))
+		copy(stack, e.Stack)
+	}
+	return &Error{Message: e.Message, Kind: e.Kind, Pos: e.Pos, Stack: stack}
+}
+
+// ErrorValue is an error a program holds as a value, once it has been caught or
+// before it is thrown
+type ErrorValue struct {
+	Error *Error
+}
+
+func (ev *ErrorValue) Type() ObjectType {
+	return ERROR_VALUE_OBJ
+}
+
+func (ev *ErrorValue) Inspect() string {
+	return ev.Error.Kind + ": " + ev.Error.Message
+}
@@ -2,6 +2,7 @@ package object
 
 import (
 	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/token"
 	"bytes"
 	"strings"
 )
@@ -12,6 +13,9 @@ type Function struct {
 	Body *ast.BlockStatement
 	ReturnType *ast.Identifier
 	Env *Environment
+	// Pos is where the function is defined, which is where one that ends without
+	// a return statement returns from
+	Pos token.Position
 }
 
 func (f *Function) Type() ObjectType {
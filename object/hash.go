@@ -0,0 +1,116 @@
+package object
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// HashKey identifies a key in a Hash. Keys of different types never collide.
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable is implemented by objects that can be used as keys in a Hash
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (f *Float) HashKey() HashKey {
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(b.Value.String()))
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash is a map from keys of KeyType to values of ValueType. Pairs are kept in
+// the order they were inserted.
+type Hash struct {
+	KeyType   string
+	ValueType string
+	Pairs     map[HashKey]HashPair
+	Order     []HashKey
+}
+
+func NewHash(keyType string, valueType string) *Hash {
+	return &Hash{KeyType: keyType, ValueType: valueType, Pairs: make(map[HashKey]HashPair), Order: []HashKey{}}
+}
+
+// Set stores the pair, keeping the key's original position if it is already present
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if _, ok := h.Pairs[key]; !ok {
+		h.Order = append(h.Order, key)
+	}
+	h.Pairs[key] = pair
+}
+
+// Delete removes the key, if present
+func (h *Hash) Delete(key HashKey) {
+	if _, ok := h.Pairs[key]; !ok {
+		return
+	}
+	delete(h.Pairs, key)
+	for i, k := range h.Order {
+		if k == key {
+			h.Order = append(h.Order[:i:i], h.Order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Copy gives a new Hash holding the same pairs
+func (h *Hash) Copy() *Hash {
+	c := NewHash(h.KeyType, h.ValueType)
+	for _, k := range h.Order {
+		c.Set(k, h.Pairs[k])
+	}
+	return c
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, k := range h.Order {
+		pair := h.Pairs[k]
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
@@ -0,0 +1,108 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Instance is an object built by calling a class. Its fields and methods live in Env.
+// An instance of a subclass is made of layers, one for each class in its hierarchy:
+// Super is the layer built by the parent class, and This is the whole instance.
+type Instance struct {
+	Class *Class
+	Env   *Environment
+	Super *Instance
+	This  *Instance
+}
+
+func (i *Instance) Type() ObjectType {
+	return INSTANCE_OBJ
+}
+
+// Get looks up a field or method of the instance, starting with the subclass
+func (i *Instance) Get(name string) (Object, bool) {
+	for layer := i; layer != nil; layer = layer.Super {
+		if obj, ok := layer.Env.GetLocal(name); ok {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// Set changes the value of a field in the layer that defines it
+func (i *Instance) Set(name string, val Object) {
+	for layer := i; layer != nil; layer = layer.Super {
+		if _, ok := layer.Env.GetLocal(name); ok {
+			layer.Env.Set(name, val)
+			return
+		}
+	}
+	i.Env.Set(name, val)
+}
+
+// IsA reports whether the instance's class is the named class, inherits from it, or
+// implements the named interface
+func (i *Instance) IsA(name string) bool {
+	for class := i.Class; class != nil; class = class.Parent {
+		if class.Name.Value == name {
+			return true
+		}
+		for _, iface := range class.Interfaces {
+			if iface.Name.Value == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Fields gives the names of the instance's fields, with the constructor's parameters
+// first and the fields of parent classes last
+func (i *Instance) Fields() []string {
+	fields := []string{}
+	seen := map[string]bool{"this": true, "super": true}
+	for layer := i; layer != nil; layer = layer.Super {
+		for _, p := range layer.Class.Parameters {
+			if !seen[p.Value] {
+				fields = append(fields, p.Value)
+				seen[p.Value] = true
+			}
+		}
+		for _, name := range layer.Env.Names() {
+			if seen[name] {
+				continue
+			}
+			switch obj, _ := layer.Env.GetLocal(name); obj.(type) {
+			case *Function, *Closure, *Builtin:
+				continue
+			}
+			fields = append(fields, name)
+			seen[name] = true
+		}
+	}
+	return fields
+}
+
+func (i *Instance) Inspect() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, name := range i.Fields() {
+		val, _ := i.Get(name)
+		if val == nil {
+			continue
+		}
+		if other, ok := val.(*Instance); ok && other.This == i.This {
+			fields = append(fields, name+": "+i.Class.Name.Value)
+			continue
+		}
+		fields = append(fields, name+": "+val.Inspect())
+	}
+
+	out.WriteString(i.Class.Name.Value)
+	out.WriteString("(")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -0,0 +1,30 @@
+package object
+
+import (
+	"bytes"
+	"github.com/OisinA/Azula/ast"
+)
+
+// Interface is a set of methods a class promises to have by implementing it
+type Interface struct {
+	Name    *ast.Identifier
+	Methods []*ast.MethodSignature
+}
+
+func (i *Interface) Type() ObjectType {
+	return INTERFACE_OBJ
+}
+
+func (i *Interface) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString("interface ")
+	out.WriteString(i.Name.Value)
+	out.WriteString(" {\n")
+	for _, m := range i.Methods {
+		out.WriteString(m.String() + "\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
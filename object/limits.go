@@ -0,0 +1,15 @@
+package object
+
+// DEFAULT_MAX_DEPTH is how many calls deep a program can go unless it is told otherwise
+const DEFAULT_MAX_DEPTH = 1000
+
+// Limits bound how much a program can do while it runs, so one that recurses or loops
+// forever raises an error rather than taking down the process running it. A limit of
+// 0 is no limit.
+type Limits struct {
+	// MaxDepth is how many calls the program can be inside at once
+	MaxDepth int
+	// MaxSteps is how many nodes the program can evaluate, and Steps how many it has
+	MaxSteps int
+	Steps    int
+}
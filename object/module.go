@@ -0,0 +1,42 @@
+package object
+
+// Module is an imported file. The evaluator keeps the names it defines in Env, while
+// the vm keeps them in global slots, found through Slots.
+type Module struct {
+	Name string
+	File string
+	Env  *Environment
+	// Slots gives the index in Globals of each name defined by the module when it is run by the vm
+	Slots   map[string]int
+	Globals []Object
+	// Init runs the module's top level when it is run by the vm
+	Init *CompiledFunction
+}
+
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
+}
+
+func (m *Module) Inspect() string {
+	return "module " + m.Name
+}
+
+// Get looks up a name defined at the top level of the module
+func (m *Module) Get(name string) (Object, bool) {
+	if m.Env != nil {
+		return m.Env.GetLocal(name)
+	}
+	slot, ok := m.Slots[name]
+	if !ok || m.Globals == nil || m.Globals[slot] == nil {
+		return nil, false
+	}
+	return m.Globals[slot], true
+}
+
+// Modules holds the modules loaded so far, so each file is only run once however
+// many times it is imported
+type Modules struct {
+	Loaded map[string]*Module
+	// Loading is the chain of modules being run, each imported by the one before it
+	Loading []string
+}
@@ -4,16 +4,29 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
+	BIGINT_OBJ       = "BIGINT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
+	ERROR_VALUE_OBJ  = "ERROR_VALUE"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
 	BUILTIN_OBJ      = "BUILTIN"
 	FOR_OBJ          = "FOR"
 	CLASS_OBJ        = "CLASS"
+	INSTANCE_OBJ     = "INSTANCE"
+	INTERFACE_OBJ    = "INTERFACE"
+	MODULE_OBJ       = "MODULE"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	CLOSURE_OBJ           = "CLOSURE"
+	CELL_OBJ              = "CELL"
 )
 
 type Object interface {
@@ -30,13 +43,24 @@ func Equality(obj1 *Object, obj2 *Object) bool {
 		int1 := ((*obj1).(*Integer))
 		int2 := ((*obj2).(*Integer))
 		return int1.Value == int2.Value
+	case FLOAT_OBJ:
+		float1 := ((*obj1).(*Float))
+		float2 := ((*obj2).(*Float))
+		return float1.Value == float2.Value
+	case BIGINT_OBJ:
+		big1 := ((*obj1).(*BigInt))
+		big2 := ((*obj2).(*BigInt))
+		return big1.Value.Cmp(big2.Value) == 0
 	case STRING_OBJ:
 		str1 := ((*obj1).(*String))
 		str2 := ((*obj2).(*String))
 		return str1.Value == str2.Value
+	case BOOLEAN_OBJ:
+		return (*obj1).(*Boolean).Value == (*obj2).(*Boolean).Value
 	default:
-		return obj1 == obj2
+		// anything else is only equal to itself
+		return *obj1 == *obj2
 	}
 }
 
-type BuiltinFunction func(args ...Object) Object
+type BuiltinFunction func(ctx *Context, args ...Object) Object
@@ -1,7 +1,13 @@
 package object
 
+import (
+	"github.com/OisinA/Azula/token"
+)
+
+// ReturnValue is the value given by a return statement, and where the statement is
 type ReturnValue struct {
 	Value Object
+	Pos   token.Position
 }
 
 func (rv *ReturnValue) Type() ObjectType {
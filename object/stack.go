@@ -0,0 +1,55 @@
+package object
+
+import (
+	"github.com/OisinA/Azula/token"
+)
+
+// MAIN is the name the traceback gives the top level of a program
+const MAIN = "<main>"
+
+// Call is a call to a function, method or class constructor, and the site it was made from
+type Call struct {
+	Function string
+	Site     token.Position
+}
+
+// CallStack holds the calls a program is in the middle of, outermost first
+type CallStack struct {
+	Calls []Call
+}
+
+func (s *CallStack) Push(function string, site token.Position) {
+	s.Calls = append(s.Calls, Call{Function: function, Site: site})
+}
+
+func (s *CallStack) Pop() {
+	s.Calls = s.Calls[:len(s.Calls)-1]
+}
+
+// Capture gives the stack to an error that doesn't have one yet, so it keeps the
+// calls it was raised in after they have returned
+func (s *CallStack) Capture(obj Object) {
+	if err, ok := obj.(*Error); ok && err.Stack == nil {
+		err.Stack = make([]Call, len(s.Calls))
+		copy(err.Stack, s.Calls)
+	}
+}
+
+// Frame is a function in an error's traceback, and where it had got to
+type Frame struct {
+	Function string
+	Pos      token.Position
+}
+
+// Traceback gives the functions an error was raised in, outermost first. Each call
+// in its stack was made from the function before it, and the error was raised in the
+// innermost one.
+func (e *Error) Traceback() []Frame {
+	frames := []Frame{}
+	function := MAIN
+	for _, call := range e.Stack {
+		frames = append(frames, Frame{Function: function, Pos: call.Site})
+		function = call.Function
+	}
+	return append(frames, Frame{Function: function, Pos: e.Pos})
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/OisinA/Azula/ast"
 	"github.com/OisinA/Azula/lexer"
@@ -12,6 +13,8 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	OR
+	AND
 	EQUALS
 	LESSGREATER
 	SUM
@@ -22,24 +25,35 @@ const (
 	INDEX
 )
 
+// precedences follow Go's, so a bitwise operator binds tighter than a comparison
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
-	token.ACCESS:   ACCESS,
+	token.OR:          OR,
+	token.AND:         AND,
+	token.EQ:          EQUALS,
+	token.NOT_EQ:      EQUALS,
+	token.LT:          LESSGREATER,
+	token.GT:          LESSGREATER,
+	token.LT_EQ:       LESSGREATER,
+	token.GT_EQ:       LESSGREATER,
+	token.PLUS:        SUM,
+	token.MINUS:       SUM,
+	token.BIT_OR:      SUM,
+	token.BIT_XOR:     SUM,
+	token.SLASH:       PRODUCT,
+	token.ASTERISK:    PRODUCT,
+	token.PERCENT:     PRODUCT,
+	token.BIT_AND:     PRODUCT,
+	token.SHIFT_LEFT:  PRODUCT,
+	token.SHIFT_RIGHT: PRODUCT,
+	token.LPAREN:      CALL,
+	token.LBRACKET:    INDEX,
+	token.ACCESS:      ACCESS,
 }
 
 type Parser struct {
 	l *lexer.Lexer
 
-	errors []string
+	errors []*Error
 
 	curToken  token.Token
 	peekToken token.Token
@@ -48,28 +62,47 @@ type Parser struct {
 	infixParseFns  map[token.TokenType]infixParseFn
 }
 
+// Error is a parse error along with the position in the source it occurred at
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
 type (
 	prefixParseFn func() ast.Expression
 	infixParseFn  func(ast.Expression) ast.Expression
 )
 
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, errors: []*Error{}}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.STRING_HEAD, p.parseInterpolatedString)
+	p.registerPrefix(token.INVALID, p.parseInvalid)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefix(token.FOR, p.parseForLoop)
+	p.registerPrefix(token.WHILE, p.parseWhileLoop)
 	p.registerPrefix(token.CLASS, p.parseClass)
+	p.registerPrefix(token.THIS, p.parseThis)
+	p.registerPrefix(token.SUPER, p.parseSuper)
+	p.registerPrefix(token.INTERFACE, p.parseInterface)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -80,9 +113,19 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_RIGHT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
-	p.registerInfix(token.ACCESS, p.parseNestedCallExpression)
+	p.registerInfix(token.ACCESS, p.parsePropertyExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -107,10 +150,24 @@ func (p *Parser) nextToken() {
 	p.peekToken = p.l.NextToken()
 }
 
+// Errors gives the formatted messages of all errors found while parsing
 func (p *Parser) Errors() []string {
+	msgs := []string{}
+	for _, err := range p.errors {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
+
+// ErrorList gives the errors found while parsing, with their positions
+func (p *Parser) ErrorList() []*Error {
 	return p.errors
 }
 
+func (p *Parser) addError(pos token.Position, format string, a ...interface{}) {
+	p.errors = append(p.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
@@ -133,8 +190,29 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReturnStatement()
 	case token.IMPORT:
 		return p.parseImportStatement()
+	case token.TRY:
+		return p.parseTryStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.BREAK:
+		stmt := &ast.BreakStatement{Token: p.curToken}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	case token.CONTINUE:
+		stmt := &ast.ContinueStatement{Token: p.curToken}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	case token.FUNCTION:
+		if p.peekTokenIs(token.LPAREN) && p.peekIsFunctionType() {
+			return p.parseLetStatement()
+		}
+		return p.parseExpressionStatement()
 	case token.IDENT:
-		if p.peekTokenIs(token.ASSIGN) {
+		if p.peekIsAssignment() {
 			return p.parseReassignStatement()
 		} else if p.peekTokenIs(token.IDENT) {
 			return p.parseLetStatement()
@@ -164,18 +242,74 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s (%s) instead", t, p.peekToken.Type, p.peekToken.Literal)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken.Pos, "expected next token to be %s, got %s (%s) instead", t, p.peekToken.Type, p.peekToken.Literal)
 }
 
-func (p *Parser) parseReassignStatement() *ast.ReassignStatement {
-	stmt := &ast.ReassignStatement{Token: p.curToken}
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+// assignOperators are the operators that assign a value, by the infix operator each
+// applies to the old and given values first
+var assignOperators = map[token.TokenType]string{
+	token.ASSIGN:          "",
+	token.PLUS_ASSIGN:     "+",
+	token.MINUS_ASSIGN:    "-",
+	token.ASTERISK_ASSIGN: "*",
+	token.SLASH_ASSIGN:    "/",
+	token.PERCENT_ASSIGN:  "%",
+}
+
+// incrementOperators add or subtract one, as in x++;
+var incrementOperators = map[token.TokenType]string{
+	token.INCREMENT: "+",
+	token.DECREMENT: "-",
+}
+
+// peekIsFunctionType reports whether the func( starting a statement is the type of a
+// declaration, as in func(int): int f = g;, rather than an anonymous function. It
+// looks ahead on a copy of the lexer, for an = before the { of a function body.
+func (p *Parser) peekIsFunctionType() bool {
+	l := *p.l
+	for tok := l.NextToken(); ; tok = l.NextToken() {
+		switch tok.Type {
+		case token.ASSIGN:
+			return true
+		case token.LBRACE, token.SEMICOLON, token.EOF:
+			return false
+		}
+	}
+}
+
+func (p *Parser) peekIsAssignment() bool {
+	_, assign := assignOperators[p.peekToken.Type]
+	_, increment := incrementOperators[p.peekToken.Type]
+	return assign || increment
+}
+
+// parseAssignment parses the operator and value of an assignment, leaving the parser
+// on its semicolon. The value of an increment is 1. It gives a nil value if either
+// couldn't be parsed.
+func (p *Parser) parseAssignment() (string, ast.Expression) {
 	p.nextToken()
+	if operator, ok := incrementOperators[p.curToken.Type]; ok {
+		one := &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1", Pos: p.curToken.Pos}, Value: 1}
+		if !p.expectPeek(token.SEMICOLON) {
+			return operator, nil
+		}
+		return operator, one
+	}
+
+	operator := assignOperators[p.curToken.Type]
 	p.nextToken()
-	stmt.Value = p.parseExpression(LOWEST)
+	value := p.parseExpression(LOWEST)
+	if value == nil || !p.expectPeek(token.SEMICOLON) {
+		return operator, nil
+	}
+	return operator, value
+}
 
-	if !p.expectPeek(token.SEMICOLON) {
+func (p *Parser) parseReassignStatement() *ast.ReassignStatement {
+	stmt := &ast.ReassignStatement{Token: p.curToken}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Operator, stmt.Value = p.parseAssignment()
+	if stmt.Value == nil {
 		return nil
 	}
 
@@ -187,7 +321,7 @@ func (p *Parser) parseClass() ast.Expression {
 	p.nextToken()
 	name := p.curToken
 
-	class := &ast.ClassLiteral{Token: cla, Name: &ast.Identifier{Token: name, Value: name.Literal}}
+	class := &ast.ClassLiteral{Token: cla, Name: &ast.Identifier{Token: name, Value: name.Literal}, Doc: cla.Doc}
 
 	if !p.expectPeek(token.LPAREN) {
 		return nil
@@ -195,6 +329,32 @@ func (p *Parser) parseClass() ast.Expression {
 
 	class.Parameters = p.parseFunctionParameters()
 
+	if p.peekTokenIs(token.EXTENDS) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		class.Parent = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			class.ParentArguments = p.parseExpressionList(token.RPAREN)
+		}
+	}
+
+	if p.peekTokenIs(token.IMPLEMENTS) {
+		p.nextToken()
+		for {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			class.Interfaces = append(class.Interfaces, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
@@ -204,31 +364,70 @@ func (p *Parser) parseClass() ast.Expression {
 	return class
 }
 
-func (p *Parser) parseLetStatement() *ast.LetStatement {
-	stmt := &ast.LetStatement{Token: p.curToken}
+func (p *Parser) parseInterface() ast.Expression {
+	iface := &ast.InterfaceLiteral{Token: p.curToken, Doc: p.curToken.Doc}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	iface.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	retType := p.curToken
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
 
-	if retType.Literal == "array" {
-		if !p.expectPeek(token.LPAREN) {
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.FUNCTION) {
 			return nil
 		}
-		if !p.expectPeek(token.LET) {
+		method := p.parseMethodSignature()
+		if method == nil {
 			return nil
 		}
-		arrayType := p.curToken
-		p.nextToken()
+		iface.Methods = append(iface.Methods, method)
+	}
+	p.nextToken()
 
-		if !p.expectPeek(token.IDENT) {
-			return nil
-		}
-		stmt.Name = &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: ast.Identifier{Token: retType, Value: arrayType.Literal}}
-	} else {
-		if !p.expectPeek(token.IDENT) {
-			return nil
-		}
-		stmt.Name = &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: ast.Identifier{Token: retType, Value: retType.Literal}}
+	return iface
+}
+
+// parseMethodSignature parses a method of an interface, like func area(): int;
+func (p *Parser) parseMethodSignature() *ast.MethodSignature {
+	method := &ast.MethodSignature{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	method.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	method.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeek(token.RETURN_TYPE) {
+		return nil
+	}
+	p.nextToken()
+	method.ReturnType = p.parseType()
+	if method.ReturnType == nil || !p.expectPeek(token.SEMICOLON) {
+		return nil
 	}
+
+	return method
+}
+
+func (p *Parser) parseLetStatement() *ast.LetStatement {
+	stmt := &ast.LetStatement{Token: p.curToken}
+
+	retType := p.parseType()
+	if retType == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: *retType}
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -238,8 +437,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	for !p.curTokenIs(token.SEMICOLON) {
 		if p.curTokenIs(token.EOF) {
-			msg := fmt.Sprintf("expected next token to be semicolon. none found.")
-			p.errors = append(p.errors, msg)
+			p.addError(p.curToken.Pos, "expected next token to be semicolon. none found.")
 			return nil
 		}
 		p.nextToken()
@@ -248,6 +446,95 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+// parseType parses the type starting at the current token. Types that take
+// arguments keep them in Value, so array(int) has a Value of "int",
+// hashmap(int, string) has a Value of "int, string" and array(array(int)) has a
+// Value of "array(int)". A function type keeps the
+// whole type, so func(int): bool has a Value of "func(int): bool".
+func (p *Parser) parseType() *ast.Identifier {
+	typ := p.curToken
+
+	if typ.Type == token.FUNCTION {
+		return p.parseFunctionType()
+	}
+
+	switch typ.Literal {
+	case "array":
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		elem := p.parseTypeArgument()
+		if elem == "" || !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+		return &ast.Identifier{Token: typ, Value: elem}
+	case "hashmap":
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		key := p.parseTypeArgument()
+		if key == "" || !p.expectPeek(token.COMMA) {
+			return nil
+		}
+		value := p.parseTypeArgument()
+		if value == "" || !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+		return &ast.Identifier{Token: typ, Value: key + ", " + value}
+	default:
+		return &ast.Identifier{Token: typ, Value: typ.Literal}
+	}
+}
+
+// parseFunctionType parses the type of a function, as in func(int, string): bool. Its
+// parameter and return types can be any type, including other function types.
+func (p *Parser) parseFunctionType() *ast.Identifier {
+	typ := p.curToken
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	params := []string{}
+	for !p.peekTokenIs(token.RPAREN) {
+		if len(params) > 0 && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+		p.nextToken()
+		param := p.parseType()
+		if param == nil {
+			return nil
+		}
+		params = append(params, ast.TypeString(param))
+	}
+	p.nextToken()
+
+	if !p.expectPeek(token.RETURN_TYPE) {
+		return nil
+	}
+	p.nextToken()
+	ret := p.parseType()
+	if ret == nil {
+		return nil
+	}
+
+	return &ast.Identifier{Token: typ, Value: "func(" + strings.Join(params, ", ") + "): " + ast.TypeString(ret)}
+}
+
+// parseTypeArgument parses the next type given to array or hashmap, which can be any
+// type, as in array(array(int)) or array(func(int): bool), giving it as it is written
+func (p *Parser) parseTypeArgument() string {
+	p.nextToken()
+	if !p.curTokenIs(token.LET) && !p.curTokenIs(token.IDENT) && !p.curTokenIs(token.FUNCTION) {
+		p.addError(p.curToken.Pos, "expected a type, got %s (%s) instead", p.curToken.Type, p.curToken.Literal)
+		return ""
+	}
+	t := p.parseType()
+	if t == nil {
+		return ""
+	}
+	return ast.TypeString(t)
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
@@ -256,15 +543,90 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
 	for !p.curTokenIs(token.SEMICOLON) {
+		if p.curTokenIs(token.EOF) {
+			p.addError(p.curToken.Pos, "expected next token to be semicolon. none found.")
+			return nil
+		}
 		p.nextToken()
 	}
 
 	return stmt
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+func (p *Parser) parseTryStatement() ast.Statement {
+	stmt := &ast.TryStatement{Token: p.curToken}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
+	for p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+		clause := p.parseCatchClause()
+		if clause == nil {
+			return nil
+		}
+		stmt.Catches = append(stmt.Catches, clause)
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if len(stmt.Catches) == 0 && stmt.Finally == nil {
+		p.addError(stmt.Token.Pos, "try needs a catch or finally block")
+		return nil
+	}
+	return stmt
+}
+
+// parseCatchClause parses a catch clause like catch (e) { } or catch (IndexError e) { }
+func (p *Parser) parseCatchClause() *ast.CatchClause {
+	clause := &ast.CatchClause{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		clause.Kind = name
+		name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	clause.Name = name
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	clause.Body = p.parseBlockStatement()
+	return clause
+}
+
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+	return stmt
+}
+
+// parseExpressionStatement parses an expression, or an assignment to a property or
+// element of one
+func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
+	if p.peekIsAssignment() {
+		switch target := stmt.Expression.(type) {
+		case *ast.PropertyExpression:
+			return p.parsePropertyAssignment(target)
+		case *ast.IndexExpression:
+			return p.parseIndexAssignment(target)
+		}
+	}
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -318,15 +680,71 @@ func (p *Parser) parseForLoop() ast.Expression {
 	return &ast.ForLiteral{Token: f, Parameter: parameter, Iterator: iterator, Body: body}
 }
 
-func (p *Parser) parseNestedCallExpression(left ast.Expression) ast.Expression {
+func (p *Parser) parseWhileLoop() ast.Expression {
+	loop := &ast.WhileLiteral{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
 	p.nextToken()
-	ident := p.parseIdentifier()
+
+	loop.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	loop.Body = p.parseBlockStatement()
+
+	return loop
+}
+
+// parsePropertyExpression parses a field access like obj.x, or a method call like obj.m()
+func (p *Parser) parsePropertyExpression(left ast.Expression) ast.Expression {
+	access := p.curToken
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.peekTokenIs(token.LPAREN) {
+		return &ast.PropertyExpression{Token: access, Object: left, Property: ident}
+	}
 	p.nextToken()
 	exp := p.parseCallExpression(ident).(*ast.CallExpression)
 	exp.Outer = left
 	return exp
 }
 
+func (p *Parser) parsePropertyAssignment(property *ast.PropertyExpression) ast.Statement {
+	stmt := &ast.PropertyAssignment{Token: p.peekToken, Property: property}
+	stmt.Operator, stmt.Value = p.parseAssignment()
+	if stmt.Value == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseIndexAssignment(index *ast.IndexExpression) ast.Statement {
+	stmt := &ast.IndexAssignment{Token: p.peekToken, Index: index}
+	stmt.Operator, stmt.Value = p.parseAssignment()
+	if stmt.Value == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThis() ast.Expression {
+	return &ast.ThisExpression{Token: p.curToken}
+}
+
+func (p *Parser) parseSuper() ast.Expression {
+	return &ast.SuperExpression{Token: p.curToken}
+}
+
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
@@ -365,8 +783,21 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos, "could not parse %q as integer", p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.addError(p.curToken.Pos, "could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
@@ -382,13 +813,69 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	return array
 }
 
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken, Keys: []ast.Expression{}, Values: []ast.Expression{}}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RETURN_TYPE) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
+	if p.peekTokenIs(token.RETURN_TYPE) {
+		return p.parseSliceExpression(exp, nil)
+	}
+
 	p.nextToken()
 
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.RETURN_TYPE) {
+		return p.parseSliceExpression(exp, exp.Index)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+// parseSliceExpression parses the rest of an index expression that turns out to be a slice,
+// from the colon after its start
+func (p *Parser) parseSliceExpression(index *ast.IndexExpression, start ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: index.Token, Left: index.Left, Start: start}
+
+	p.nextToken()
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -433,8 +920,7 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken.Pos, "no prefix parse function for %s found", t)
 }
 
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
@@ -501,11 +987,15 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	return block
 }
 
+// parseFunctionLiteral parses a function definition, or an anonymous function if
+// there is no name before its parameters
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	def := p.curToken
-	p.nextToken()
-	name := p.curToken
-	lit := &ast.FunctionLiteral{Token: def, Name: &ast.Identifier{Token: name, Value: name.Literal}}
+	lit := &ast.FunctionLiteral{Token: def, Doc: def.Doc}
+	if !p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		lit.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
 
 	if !p.expectPeek(token.LPAREN) {
 		return nil
@@ -518,19 +1008,9 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	}
 
 	p.nextToken()
-	if p.curToken.Literal == "array" {
-		array := p.curToken
-		if !p.expectPeek(token.LPAREN) {
-			return nil
-		}
-		if !p.expectPeek(token.LET) {
-			return nil
-		}
-		arrayType := p.curToken
-		p.nextToken()
-		lit.ReturnType = &ast.Identifier{Token: array, Value: arrayType.Literal}
-	} else {
-		lit.ReturnType = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	lit.ReturnType = p.parseType()
+	if lit.ReturnType == nil {
+		return nil
 	}
 
 	if !p.expectPeek(token.LBRACE) {
@@ -547,6 +1027,14 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 	p.nextToken()
 	str := p.parseStringLiteral()
 	stmt := &ast.ImportStatement{Token: imp, Value: str}
+	// as is only a keyword here, so it can still be used as a name elsewhere
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "as" {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
 	if !p.expectPeek(token.SEMICOLON) {
 		return nil
 	}
@@ -557,6 +1045,40 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// parseInterpolatedString parses a string with expressions in it, from the text before
+// its first ${ up to the text after its last }
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+
+	for {
+		if p.curToken.Literal != "" {
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		}
+		if p.curTokenIs(token.STRING_TAIL) {
+			return str
+		}
+
+		p.nextToken()
+		if p.curTokenIs(token.STRING_MIDDLE) || p.curTokenIs(token.STRING_TAIL) {
+			p.addError(p.curToken.Pos, "expected an expression in ${}")
+			continue
+		}
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.STRING_MIDDLE) && !p.peekTokenIs(token.STRING_TAIL) {
+			p.peekError(token.STRING_TAIL)
+			return nil
+		}
+		p.nextToken()
+	}
+}
+
+// parseInvalid reports source the lexer couldn't read
+func (p *Parser) parseInvalid() ast.Expression {
+	p.addError(p.curToken.Pos, "%s", p.curToken.Literal)
+	return nil
+}
+
 func (p *Parser) parseFunctionParameters() []*ast.TypedIdentifier {
 	identifiers := []*ast.TypedIdentifier{}
 
@@ -567,17 +1089,23 @@ func (p *Parser) parseFunctionParameters() []*ast.TypedIdentifier {
 
 	p.nextToken()
 
-	identType := p.curToken
+	identType := p.parseType()
+	if identType == nil {
+		return nil
+	}
 	p.nextToken()
-	ident := &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: ast.Identifier{Token: identType, Value: identType.Literal}}
+	ident := &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: *identType}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		identType = p.curToken
+		identType = p.parseType()
+		if identType == nil {
+			return nil
+		}
 		p.nextToken()
-		ident := &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: ast.Identifier{Token: identType, Value: identType.Literal}}
+		ident := &ast.TypedIdentifier{Token: p.curToken, Value: p.curToken.Literal, ReturnType: *identType}
 		identifiers = append(identifiers, ident)
 	}
 
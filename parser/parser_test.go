@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/OisinA/Azula/ast"
@@ -71,6 +72,21 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestReturnWithoutSemicolon(t *testing.T) {
+	// the parser used to look for the semicolon past the end of the input forever
+	l := lexer.New(`func f(int a): int { return a * 2 }`)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+	if !strings.Contains(errors[0], "expected next token to be semicolon") {
+		t.Errorf("wrong error. got=%q", errors[0])
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -160,6 +176,31 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "1.25;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 1.25 {
+		t.Errorf("literal.Value not %f. got=%f", 1.25, literal.Value)
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := "true;"
 
@@ -249,6 +290,21 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 	return true
 }
 
+func testStringLiteral(t *testing.T, sl ast.Expression, value string) bool {
+	str, ok := sl.(*ast.StringLiteral)
+	if !ok {
+		t.Errorf("sl not *ast.StringLiteral. got=%T", sl)
+		return false
+	}
+
+	if str.Value != value {
+		t.Errorf("str.Value not %q. got=%q", value, str.Value)
+		return false
+	}
+
+	return true
+}
+
 func TestParsingInfixExpressions(t *testing.T) {
 	infixTests := []struct {
 		input      string
@@ -517,6 +573,42 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * [1, 2, 3, 4][b * c] * d",
 			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
 		},
+		{
+			"a % b * c",
+			"((a % b) * c)",
+		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"a <= b == b >= a",
+			"((a <= b) == (b >= a))",
+		},
+		{
+			"a > 3 && a < 10 || b",
+			"(((a > 3) && (a < 10)) || b)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a & 1 == 0",
+			"((a & 1) == 0)",
+		},
+		{
+			"a | b ^ c & d",
+			"((a | b) ^ (c & d))",
+		},
+		{
+			"1 << a + 1",
+			"((1 << a) + 1)",
+		},
+		{
+			"~a >> 2",
+			"((~a) >> 2)",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -682,6 +774,66 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		parts    int
+	}{
+		{`"hello ${name}, you are ${age + 1}";`, "hello ${name}, you are ${(age + 1)}", 4},
+		{`"${a}${b}";`, "${a}${b}", 2},
+		{`"${"in ${x}"}!";`, "${in ${x}}!", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+		if str.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, str.String())
+		}
+		if len(str.Parts) != tt.parts {
+			t.Errorf("wrong number of parts for %q. expected=%d, got=%d", tt.input, tt.parts, len(str.Parts))
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`"open;`, "unterminated string literal"},
+		{"`open;", "unterminated raw string literal"},
+		{`"\x";`, "unknown escape sequence \\x"},
+		{`"\u{110000}";`, "invalid code point \\u{110000}"},
+		{`"\u41";`, "\\u must be followed by a code point in braces"},
+		{`"a ${} b";`, "expected an expression in ${}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if !strings.Contains(errors[0], tt.expectedMessage) {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errors[0])
+		}
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -705,6 +857,153 @@ func TestParsingArrayLiterals(t *testing.T) {
 	testInfixExpression(t, array.Elements[2], 3, "+", 3)
 }
 
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{"one": 1, "two": 2 + 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Keys) != 2 || len(hash.Values) != 2 {
+		t.Fatalf("hash has wrong number of pairs. got=%d", len(hash.Keys))
+	}
+
+	testStringLiteral(t, hash.Keys[0], "one")
+	testStringLiteral(t, hash.Keys[1], "two")
+	testIntegerLiteral(t, hash.Values[0], 1)
+	testInfixExpression(t, hash.Values[1], 2, "+", 3)
+}
+
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	input := "{}"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Keys) != 0 {
+		t.Errorf("hash.Keys has wrong length. got=%d", len(hash.Keys))
+	}
+}
+
+func TestParsingTypes(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedKind  string
+		expectedValue string
+	}{
+		{"int x = 1;", "int", "int"},
+		{"array(string) x = [];", "array", "string"},
+		{"hashmap(int, string) x = {};", "hashmap", "int, string"},
+		{"hashmap(string, Point) x = {};", "hashmap", "string, Point"},
+		{"func(int, array(string)): func(): bool f = g;", "func", "func(int, array(string)): func(): bool"},
+		{"func(): void f = func(): void { };", "func", "func(): void"},
+		{"array(array(int)) x = [];", "array", "array(int)"},
+		{"array(func(int): bool) x = [];", "array", "func(int): bool"},
+		{"hashmap(string, hashmap(int, array(string))) x = {};", "hashmap", "string, hashmap(int, array(string))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Name.ReturnType.Token.Literal != tt.expectedKind {
+			t.Errorf("type kind wrong. expected=%q, got=%q", tt.expectedKind, stmt.Name.ReturnType.Token.Literal)
+		}
+		if stmt.Name.ReturnType.Value != tt.expectedValue {
+			t.Errorf("type value wrong. expected=%q, got=%q", tt.expectedValue, stmt.Name.ReturnType.Value)
+		}
+	}
+}
+
+func TestParsingTypedParameters(t *testing.T) {
+	input := "func lookup(hashmap(string, int) m, array(string) names): hashmap(string, int) { m; }"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d", len(function.Parameters))
+	}
+	if function.Parameters[0].Value != "m" || function.Parameters[0].ReturnType.Value != "string, int" {
+		t.Errorf("first parameter wrong. got=%s %s", function.Parameters[0].ReturnType.Value, function.Parameters[0].Value)
+	}
+	if function.Parameters[1].Value != "names" || function.Parameters[1].ReturnType.Value != "string" {
+		t.Errorf("second parameter wrong. got=%s %s", function.Parameters[1].ReturnType.Value, function.Parameters[1].Value)
+	}
+	if function.ReturnType.TokenLiteral() != "hashmap" || function.ReturnType.Value != "string, int" {
+		t.Errorf("return type wrong. got=%s(%s)", function.ReturnType.TokenLiteral(), function.ReturnType.Value)
+	}
+}
+
+func TestAnonymousFunctions(t *testing.T) {
+	tests := []struct {
+		input  string
+		params int
+	}{
+		{"func(int x): int { x; }(5);", 1},
+		{"apply(func(int a, int b): int { a + b; });", 2},
+		{"func(): void { };", 0},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+		exp := stmt.Expression
+		if call, ok := exp.(*ast.CallExpression); ok {
+			exp = call.Function
+			if _, ok := exp.(*ast.FunctionLiteral); !ok {
+				exp = call.Arguments[0]
+			}
+		}
+		function, ok := exp.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("%q: expected a function literal. got=%T", tt.input, exp)
+		}
+		if function.Name != nil {
+			t.Errorf("%q: expected an anonymous function. got name %s", tt.input, function.Name)
+		}
+		if len(function.Parameters) != tt.params {
+			t.Errorf("%q: wrong number of parameters. want=%d, got=%d", tt.input, tt.params, len(function.Parameters))
+		}
+	}
+}
+
 func TestParsingIndexExpressions(t *testing.T) {
 	input := "myArray[1 + 1]"
 
@@ -728,6 +1027,33 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs[1:a + 1]", "(xs[1:(a + 1)])"},
+		{"xs[:2]", "(xs[:2])"},
+		{"xs[-2:]", "(xs[(-2):])"},
+		{"xs[:]", "(xs[:])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.SliceExpression); !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+		if stmt.Expression.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, stmt.Expression.String())
+		}
+	}
+}
+
 func TestForLoopsExpression(t *testing.T) {
 	input := "for(p in x) { int i = i + p; }"
 
@@ -742,6 +1068,37 @@ func TestForLoopsExpression(t *testing.T) {
 	}
 }
 
+func TestWhileLoopExpression(t *testing.T) {
+	input := "while(x < 10) { x = x + 1; continue; break; }"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	loop, ok := stmt.Expression.(*ast.WhileLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.WhileLiteral. got=%T", stmt.Expression)
+	}
+
+	testInfixExpression(t, loop.Condition, "x", "<", 10)
+
+	if len(loop.Body.Statements) != 3 {
+		t.Fatalf("loop body has wrong number of statements. got=%d", len(loop.Body.Statements))
+	}
+	if _, ok := loop.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("loop.Body.Statements[1] is not ast.ContinueStatement. got=%T", loop.Body.Statements[1])
+	}
+	if _, ok := loop.Body.Statements[2].(*ast.BreakStatement); !ok {
+		t.Errorf("loop.Body.Statements[2] is not ast.BreakStatement. got=%T", loop.Body.Statements[2])
+	}
+}
+
 func TestReassignStatement(t *testing.T) {
 	input := "i = 5;"
 
@@ -832,6 +1189,253 @@ func TestClassCall(t *testing.T) {
 	testLiteralExpression(t, exp.Arguments[1], 10)
 }
 
+func TestClassExtends(t *testing.T) {
+	tests := []struct {
+		input      string
+		parent     string
+		parentArgs []string
+	}{
+		{"class Dog(string name) extends Animal { }", "Animal", nil},
+		{"class Dog(string name) extends Animal(name, 4) { }", "Animal", []string{"name", "4"}},
+		{"class Dog() extends Animal() { }", "Animal", []string{}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		class, ok := stmt.Expression.(*ast.ClassLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.ClassLiteral. got=%T", stmt.Expression)
+		}
+		if class.Parent == nil || class.Parent.Value != tt.parent {
+			t.Fatalf("class has wrong parent. got=%v", class.Parent)
+		}
+		if (class.ParentArguments == nil) != (tt.parentArgs == nil) {
+			t.Fatalf("expected parent arguments %v. got=%v", tt.parentArgs, class.ParentArguments)
+		}
+		for i, arg := range tt.parentArgs {
+			if class.ParentArguments[i].String() != arg {
+				t.Errorf("parent argument %d is not %s. got=%s", i, arg, class.ParentArguments[i].String())
+			}
+		}
+	}
+}
+
+func TestInterfaceLiteral(t *testing.T) {
+	input := `interface Shape {
+		func area(): int;
+		func scale(int by, float f): Shape;
+	}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	iface, ok := stmt.Expression.(*ast.InterfaceLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.InterfaceLiteral. got=%T", stmt.Expression)
+	}
+	if iface.Name.Value != "Shape" {
+		t.Fatalf("interface has wrong name. got=%s", iface.Name.Value)
+	}
+
+	expected := []string{"func area(): int;", "func scale(int by, float f): Shape;"}
+	if len(iface.Methods) != len(expected) {
+		t.Fatalf("interface has wrong number of methods. got=%d", len(iface.Methods))
+	}
+	for i, m := range iface.Methods {
+		if m.String() != expected[i] {
+			t.Errorf("method %d is not %q. got=%q", i, expected[i], m.String())
+		}
+	}
+}
+
+func TestClassImplements(t *testing.T) {
+	tests := []struct {
+		input      string
+		interfaces []string
+	}{
+		{"class Square(int s) implements Shape { }", []string{"Shape"}},
+		{"class Dog() extends Animal implements Pet, Named { }", []string{"Pet", "Named"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		class, ok := stmt.Expression.(*ast.ClassLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.ClassLiteral. got=%T", stmt.Expression)
+		}
+		if len(class.Interfaces) != len(tt.interfaces) {
+			t.Fatalf("class has wrong number of interfaces. got=%d", len(class.Interfaces))
+		}
+		for i, name := range tt.interfaces {
+			if class.Interfaces[i].Value != name {
+				t.Errorf("interface %d is not %s. got=%s", i, name, class.Interfaces[i].Value)
+			}
+		}
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `# Shape is anything with an area.
+interface Shape {
+	func area(): int;
+}
+
+#[ Square is a shape
+   with equal sides. ]#
+class Square(int side) implements Shape {
+	// area gives the side squared.
+	func area(): int {
+		return side * side;
+	}
+}
+
+# not about the next line
+
+func undocumented(): int {
+	return 0;
+}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+
+	iface, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InterfaceLiteral)
+	if !ok {
+		t.Fatalf("statement 0 is not *ast.InterfaceLiteral. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if iface.Doc != "Shape is anything with an area." {
+		t.Errorf("interface doc wrong. got=%q", iface.Doc)
+	}
+
+	class, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.ClassLiteral)
+	if !ok {
+		t.Fatalf("statement 1 is not *ast.ClassLiteral. got=%T", program.Statements[1].(*ast.ExpressionStatement).Expression)
+	}
+	if class.Doc != "Square is a shape\nwith equal sides." {
+		t.Errorf("class doc wrong. got=%q", class.Doc)
+	}
+	method := class.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if method.Doc != "area gives the side squared." {
+		t.Errorf("method doc wrong. got=%q", method.Doc)
+	}
+
+	fn, ok := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("statement 2 is not *ast.FunctionLiteral. got=%T", program.Statements[2].(*ast.ExpressionStatement).Expression)
+	}
+	if fn.Doc != "" {
+		t.Errorf("function doc wrong. expected none, got=%q", fn.Doc)
+	}
+}
+
+func TestPropertyExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"p.x;", "p.x"},
+		{"p.move(1, 2);", "p.move(1, 2)"},
+		{"this.x;", "this.x"},
+		{"super.speak();", "super.speak()"},
+		{"a.b.c;", "a.b.c"},
+		{"p.x + 1;", "(p.x + 1)"},
+		{"p.x = 5;", "p.x = 5;"},
+		{"this.count = this.count + 1;", "this.count = (this.count + 1);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestPropertyAssignment(t *testing.T) {
+	input := "p.x = 5;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.PropertyAssignment)
+	if !ok {
+		t.Fatalf("stmt is not ast.PropertyAssignment. got=%T", program.Statements[0])
+	}
+	if !testIdentifier(t, stmt.Property.Object, "p") {
+		return
+	}
+	if stmt.Property.Property.Value != "x" {
+		t.Errorf("property is not x. got=%s", stmt.Property.Property.Value)
+	}
+	testLiteralExpression(t, stmt.Value, 5)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5;", "x = 5;"},
+		{"x += 5 * 2;", "x += (5 * 2);"},
+		{"x -= 1;", "x -= 1;"},
+		{"x *= 2;", "x *= 2;"},
+		{"x /= 2;", "x /= 2;"},
+		{"x %= 2;", "x %= 2;"},
+		{"x++;", "x += 1;"},
+		{"x--;", "x -= 1;"},
+		{"xs[0] = 5;", "(xs[0]) = 5;"},
+		{"xs[i + 1] += 2;", "(xs[(i + 1)]) += 2;"},
+		{"grid[0][1]++;", "((grid[0])[1]) += 1;"},
+		{"p.x *= 3;", "p.x *= 3;"},
+		{"this.n--;", "this.n -= 1;"},
+		{"p.cells[0] = 1;", "(p.cells[0]) = 1;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement for %q. got=%d", tt.input, len(program.Statements))
+		}
+		if actual := program.Statements[0].String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
 func TestImport(t *testing.T) {
 	input := `import "path/string.azl";`
 	l := lexer.New(input)
@@ -844,3 +1448,110 @@ func TestImport(t *testing.T) {
 		t.Fatalf("program.Statements does not contain %d statements.got=%d\n", 1, len(program.Statements))
 	}
 }
+
+func TestImportAs(t *testing.T) {
+	tests := []struct {
+		input string
+		path  string
+		alias string
+	}{
+		{`import "strings" as s;`, "strings", "s"},
+		{`import "../lib/util.azl";`, "../lib/util.azl", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ImportStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Value.String() != tt.path {
+			t.Errorf("import path is not %q. got=%q", tt.path, stmt.Value.String())
+		}
+		if tt.alias == "" {
+			if stmt.Alias != nil {
+				t.Errorf("expected no alias. got=%s", stmt.Alias.Value)
+			}
+			continue
+		}
+		if stmt.Alias == nil || stmt.Alias.Value != tt.alias {
+			t.Errorf("alias is not %s. got=%v", tt.alias, stmt.Alias)
+		}
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	input := `try { risky(); } catch (IndexError e) { 1; } catch (e) { 2; } finally { 3; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.TryStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("try body does not contain 1 statement. got=%d", len(stmt.Body.Statements))
+	}
+	if len(stmt.Catches) != 2 {
+		t.Fatalf("try does not have 2 catch clauses. got=%d", len(stmt.Catches))
+	}
+	if stmt.Catches[0].Kind == nil || stmt.Catches[0].Kind.Value != "IndexError" {
+		t.Errorf("first catch kind is not IndexError. got=%v", stmt.Catches[0].Kind)
+	}
+	if stmt.Catches[0].Name.Value != "e" {
+		t.Errorf("first catch name is not e. got=%s", stmt.Catches[0].Name.Value)
+	}
+	if stmt.Catches[1].Kind != nil {
+		t.Errorf("second catch should catch every kind. got=%s", stmt.Catches[1].Kind.Value)
+	}
+	if stmt.Finally == nil || len(stmt.Finally.Statements) != 1 {
+		t.Errorf("finally block not parsed. got=%v", stmt.Finally)
+	}
+}
+
+func TestTryErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`try { 1; }`, "try needs a catch or finally block"},
+		{`throw "x"`, "expected next token to be ;, got EOF"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if !strings.Contains(errors[0], tt.expectedMessage) {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errors[0])
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	l := lexer.New(`throw error("KeyError", "missing");`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ThrowStatement. got=%T", program.Statements[0])
+	}
+	if _, ok := stmt.Value.(*ast.CallExpression); !ok {
+		t.Errorf("thrown value is not ast.CallExpression. got=%T", stmt.Value)
+	}
+}
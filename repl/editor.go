@@ -0,0 +1,211 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errInterrupted is given by the editor when ctrl-c abandons what is being entered
+var errInterrupted = errors.New("interrupted")
+
+// editor reads lines from a terminal, letting them be edited before they are entered
+// and earlier lines be brought back with the up and down arrows. It draws the line
+// itself, so expects the terminal to be in raw mode while it reads, and lines to fit
+// on one row of the terminal.
+type editor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history []string
+	// raw puts the terminal into raw mode, giving a function that puts it back
+	raw func() (func(), error)
+}
+
+func newEditor(in *bufio.Reader, out io.Writer, history []string, raw func() (func(), error)) *editor {
+	return &editor{in: in, out: out, history: history, raw: raw}
+}
+
+// line is the line being edited, with the cursor before the rune at pos
+type line struct {
+	buf []rune
+	pos int
+}
+
+func (l *line) insert(r rune) {
+	l.buf = append(l.buf[:l.pos], append([]rune{r}, l.buf[l.pos:]...)...)
+	l.pos++
+}
+
+// remove deletes the runes from start up to end, leaving the cursor at start
+func (l *line) remove(start int, end int) {
+	l.buf = append(l.buf[:start], l.buf[end:]...)
+	l.pos = start
+}
+
+func (l *line) set(s string) {
+	l.buf = []rune(s)
+	l.pos = len(l.buf)
+}
+
+// wordStart gives where the word before the cursor starts, after any spaces before it
+func (l *line) wordStart() int {
+	i := l.pos
+	for i > 0 && l.buf[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && l.buf[i-1] != ' ' {
+		i--
+	}
+	return i
+}
+
+// readLine reads a line after showing the prompt. It gives io.EOF for ctrl-d on an empty
+// line, and errInterrupted for ctrl-c.
+func (e *editor) readLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	l := &line{}
+	// recalled is the history entry being shown, and saved the line being entered
+	// before going back through the history
+	recalled, saved := len(e.history), ""
+	e.refresh(prompt, l)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(l.buf) > 0 {
+				break
+			}
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			io.WriteString(e.out, "\r\n")
+			return e.enter(string(l.buf)), nil
+		case 1: // ctrl-a
+			l.pos = 0
+		case 2: // ctrl-b
+			if l.pos > 0 {
+				l.pos--
+			}
+		case 3: // ctrl-c
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // ctrl-d
+			if len(l.buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if l.pos < len(l.buf) {
+				l.remove(l.pos, l.pos+1)
+			}
+		case 5: // ctrl-e
+			l.pos = len(l.buf)
+		case 6: // ctrl-f
+			if l.pos < len(l.buf) {
+				l.pos++
+			}
+		case 8, 127: // backspace
+			if l.pos > 0 {
+				l.remove(l.pos-1, l.pos)
+			}
+		case 11: // ctrl-k
+			l.remove(l.pos, len(l.buf))
+		case 21: // ctrl-u
+			l.remove(0, l.pos)
+		case 23: // ctrl-w
+			l.remove(l.wordStart(), l.pos)
+		case 27: // the start of an escape sequence, for the arrow and other keys
+			switch e.escape() {
+			case "[A", "OA":
+				if recalled > 0 {
+					if recalled == len(e.history) {
+						saved = string(l.buf)
+					}
+					recalled--
+					l.set(e.history[recalled])
+				}
+			case "[B", "OB":
+				if recalled < len(e.history) {
+					recalled++
+					if recalled == len(e.history) {
+						l.set(saved)
+					} else {
+						l.set(e.history[recalled])
+					}
+				}
+			case "[C", "OC":
+				if l.pos < len(l.buf) {
+					l.pos++
+				}
+			case "[D", "OD":
+				if l.pos > 0 {
+					l.pos--
+				}
+			case "[H", "OH", "[1~", "[7~":
+				l.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				l.pos = len(l.buf)
+			case "[3~":
+				if l.pos < len(l.buf) {
+					l.remove(l.pos, l.pos+1)
+				}
+			}
+		default:
+			// other control characters, tab among them, would throw out where the
+			// cursor is drawn
+			if r >= ' ' {
+				l.insert(r)
+			}
+		}
+		e.refresh(prompt, l)
+	}
+	io.WriteString(e.out, "\r\n")
+	return e.enter(string(l.buf)), nil
+}
+
+// escape reads the rest of an escape sequence after the escape character, which is
+// either [ followed by numbers and a final character, or O and a character
+func (e *editor) escape() string {
+	var seq strings.Builder
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != '[' && r != 'O' {
+		return ""
+	}
+	first := r
+	seq.WriteRune(r)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq.WriteRune(r)
+		if first == 'O' || (r < '0' || r > '9') && r != ';' {
+			return seq.String()
+		}
+	}
+}
+
+// enter adds a line to the history, unless it is empty or the same as the last one
+func (e *editor) enter(s string) string {
+	if strings.TrimSpace(s) != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != s) {
+		e.history = append(e.history, s)
+	}
+	return s
+}
+
+// refresh redraws the line, clearing whatever was after it, then moves the cursor back
+// to where it is in the line
+func (e *editor) refresh(prompt string, l *line) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(l.buf))
+	if back := len(l.buf) - l.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
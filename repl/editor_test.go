@@ -0,0 +1,77 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEditor(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected []string
+	}{
+		{"abc\r", []string{"abc"}},
+		{"héllo\r", []string{"héllo"}},
+		// the left arrow twice, then typing in the middle
+		{"abc\x1b[D\x1b[DX\r", []string{"aXbc"}},
+		{"abc\x02\x02\x7f\r", []string{"bc"}},
+		{"abc\x01X\x05Y\r", []string{"XabcY"}},
+		{"abc\x1b[H\x1b[3~\r", []string{"bc"}},
+		{"abcdef\x02\x02\x02\x0b\r", []string{"abc"}},
+		{"abcdef\x02\x02\x15\r", []string{"ef"}},
+		{"int x = 5\x17\r", []string{"int x = "}},
+		{"a\tb\r", []string{"ab"}},
+		// the up arrow brings back the lines entered before, and down goes forwards again
+		{"one\rtwo\r\x1b[A\x1b[A\r", []string{"one", "two", "one"}},
+		{"one\rthr\x1b[A\x1b[B\x1b[Bee\r", []string{"one", "three"}},
+		{"one\r\x1b[A\x1b[A\x1b[A!\r", []string{"one", "one!"}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := newEditor(bufio.NewReader(strings.NewReader(tt.keys)), &out, nil, nil)
+		for i, expected := range tt.expected {
+			line, err := e.readLine(PROMPT)
+			if err != nil {
+				t.Fatalf("%q: line %d: unexpected error %s", tt.keys, i, err)
+			}
+			if line != expected {
+				t.Errorf("%q: line %d wrong. expected=%q, got=%q", tt.keys, i, expected, line)
+			}
+		}
+	}
+}
+
+func TestEditorControl(t *testing.T) {
+	var out bytes.Buffer
+	e := newEditor(bufio.NewReader(strings.NewReader("ab\x03\x04")), &out, []string{"old"}, nil)
+	if _, err := e.readLine(PROMPT); err != errInterrupted {
+		t.Errorf("ctrl-c didn't interrupt. got=%v", err)
+	}
+	if _, err := e.readLine(PROMPT); err != io.EOF {
+		t.Errorf("ctrl-d on an empty line didn't end the input. got=%v", err)
+	}
+	if len(e.history) != 1 {
+		t.Errorf("interrupted line was added to the history. got=%q", e.history)
+	}
+
+	// the line is redrawn with the cursor moved back to where it is
+	out.Reset()
+	e = newEditor(bufio.NewReader(strings.NewReader("ab\x1b[D\r")), &out, nil, nil)
+	e.readLine(PROMPT)
+	if !strings.Contains(out.String(), "\r>> ab\x1b[K\x1b[1D") {
+		t.Errorf("line not redrawn. got=%q", out.String())
+	}
+
+	// a session reads its entries through the editor when it has one
+	out.Reset()
+	s := newSession(strings.NewReader("[1,\r2];\r"), &out, "")
+	s.editor = newEditor(s.reader, &out, nil, nil)
+	s.run()
+	if !strings.Contains(out.String(), "[1, 2]\n") {
+		t.Errorf("entry not run. got=%q", out.String())
+	}
+}
@@ -1,53 +1,102 @@
 package repl
 
 import (
-	"github.com/OisinA/Azula/lexer"
 	"github.com/OisinA/Azula/parser"
-	"github.com/OisinA/Azula/evaluator"
 	"github.com/OisinA/Azula/object"
-	"bufio"
+	"github.com/OisinA/Azula/token"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
-const PROMPT = ">> "
-
+// Start runs a session reading entries from in, keeping the history of what is
+// entered in the user's home directory. On a terminal, lines can be edited as they're
+// entered and earlier ones brought back. Programs read their input from in too, and
+// write their output and errors to out.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
-	for {
-		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
-			return
-		}
-		line := scanner.Text()
-		if line == "exit" {
-			fmt.Println("Goodbye :)")
-			break
-		}
-		l := lexer.New(line)
-		p := parser.New(l)
+	history := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		history = filepath.Join(home, HISTORY_FILE)
+	}
+	s := newSession(in, out, history)
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		s.useTerminal(f)
+	}
+	s.run()
+}
 
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
-			continue
-		}
+func printParserErrors(out io.Writer, source string, errors []*parser.Error) {
+	io.WriteString(out, "parser errors:\n")
+	for _, err := range errors {
+		io.WriteString(out, FormatError(source, err.Pos, err.Message))
+	}
+}
+
+// FormatError renders msg prefixed with its position, followed by the offending
+// line of source and a caret under the column the error occurred at
+func FormatError(source string, pos token.Position, msg string) string {
+	if !pos.IsValid() {
+		return msg + "\n"
+	}
+
+	out := pos.String() + ": " + msg + "\n"
 
-		evaluated := evaluator.Eval(program, env)
+	lines := strings.Split(source, "\n")
+	if pos.Line > len(lines) {
+		return out
+	}
+	line := strings.TrimRight(lines[pos.Line-1], "\r")
+	out += line + "\n"
 
-		evaluated, ok := evaluated.(*object.Error)
-		if ok {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+	// keep tabs in the padding so the caret lines up with the source line
+	padding := []rune{}
+	for _, c := range line {
+		if len(padding) >= pos.Column-1 {
+			break
+		}
+		if c == '\t' {
+			padding = append(padding, '\t')
+		} else {
+			padding = append(padding, ' ')
 		}
 	}
+	out += string(padding) + "^\n"
+
+	return out
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	io.WriteString(out, "parser errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+// FormatTrace renders a runtime error. One raised inside calls gets a traceback like
+// Python's, most recent call last, with the line of source each call had got to.
+// sourceOf gives the source of the file a position is in, or "" if it isn't known.
+func FormatTrace(err *object.Error, sourceOf func(pos token.Position) string) string {
+	if len(err.Stack) == 0 {
+		return FormatError(sourceOf(err.Pos), err.Pos, err.Inspect())
+	}
+
+	out := "Traceback (most recent call last):\n"
+	frames := err.Traceback()
+	for i, frame := range frames {
+		// a function recursing gives the same frame over and over, so only the first
+		// few are shown
+		repeats := 0
+		for i > repeats && frames[i-repeats-1] == frame {
+			repeats++
+		}
+		if repeats >= 3 {
+			if i == len(frames)-1 || frames[i+1] != frame {
+				out += fmt.Sprintf("  [Previous line repeated %d more times]\n", repeats-2)
+			}
+			continue
+		}
+		out += fmt.Sprintf("  %s, in %s\n", frame.Pos, frame.Function)
+		lines := strings.Split(sourceOf(frame.Pos), "\n")
+		if frame.Pos.IsValid() && frame.Pos.Line <= len(lines) {
+			if line := strings.TrimSpace(lines[frame.Pos.Line-1]); line != "" {
+				out += "    " + line + "\n"
+			}
+		}
 	}
+	return out + err.Kind + ": " + err.Message + "\n"
 }
@@ -0,0 +1,96 @@
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runSession(t *testing.T, input string, history string) string {
+	t.Helper()
+	var out bytes.Buffer
+	newSession(strings.NewReader(input), &out, history).run()
+	return out.String()
+}
+
+func TestSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azula-repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lib := filepath.Join(dir, "lib.azl")
+	if err := ioutil.WriteFile(lib, []byte("func square(int x): int {\n\treturn x * x;\n}\nint answer = 42;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"1 + 2;\n", []string{">> 3\n"}},
+		{"int x = 5;\nx;\n", []string{">> >> 5\n"}},
+		{"print(\"hi\");\n", []string{">> hi\n>> "}},
+		{"func f(int a): int {\nreturn a * 2;\n}\nf(4);\n", []string{">> .. .. >> 8\n"}},
+		{"[1,\n2];\n", []string{">> .. [1, 2]\n"}},
+		{"\"{\";\n", []string{">> {\n"}},
+		{"`a\nb`;\n", []string{">> .. a\nb\n"}},
+		{"\"x ${1 +\n2}\";\n", []string{">> .. x 3\n"}},
+		{"y;\n", []string{"identifier not found: y", "y;\n^"}},
+		{"\"é\" + ü;\n", []string{"identifier not found: ü", "\"é\" + ü;\n      ^"}},
+		{"func f(int a): int {\nreturn a * 2;\n}\n:type f\n", []string{"func(int): int\n"}},
+		{":type [\"a\"]\n", []string{"array(string)\n"}},
+		// :type works the type out without running anything
+		{"int x = 5;\n:type x++\nx;\n", []string{">> void\n>> 5\n"}},
+		{":type print(1)\n", []string{">> void\n>> "}},
+		{"int x = 5;\n:type x + 1.0\n", []string{"type mismatch: int + float"}},
+		{":type int y = 2\ny;\n", []string{"void\n", "identifier not found: y"}},
+		{"int x = 5;\nx = \"s\";\nx;\n", []string{"can't assign value of type string to variable of type int", ">> 5\n"}},
+		// an entry with type errors isn't run, so nothing it defines is kept
+		{"int x = \"a\";\nx + 1;\n", []string{"trying to assign string to int x", "type errors:\n1:1: identifier not found: x"}},
+		{"int x = 5;\n:env\n", []string{"x: int = 5\n"}},
+		{":load " + lib + "\nsquare(answer);\n", []string{"1764\n"}},
+		{":load " + filepath.Join(dir, "missing.azl") + "\n", []string{"couldn't read file"}},
+		{"int x = 5;\n:reset\nx;\n", []string{"environment reset\n", "identifier not found: x"}},
+		{":help\n", []string{":load file.azl", ":quit"}},
+		{":what\n", []string{"unknown command :what"}},
+		{":quit\n1;\n", []string{"Goodbye :)\n"}},
+		{"exit\n", []string{"Goodbye :)\n"}},
+		// an entry that isn't finished when the input ends still runs
+		{"[1,\n2", []string{"expected next token to be ]"}},
+	}
+
+	for _, tt := range tests {
+		out := runSession(t, tt.input, "")
+		for _, expected := range tt.expected {
+			if !strings.Contains(out, expected) {
+				t.Errorf("output for %q doesn't contain %q. got=%q", tt.input, expected, out)
+			}
+		}
+	}
+
+	out := runSession(t, ":quit\n1 + 1;\n", "")
+	if strings.Contains(out, "2\n") {
+		t.Errorf("session carried on after :quit. got=%q", out)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azula-repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	history := filepath.Join(dir, HISTORY_FILE)
+
+	runSession(t, "int x = 1;\n\nfunc f(): int {\nreturn x;\n}\n", history)
+	out := runSession(t, ":history\n", history)
+
+	expected := "int x = 1;\nfunc f(): int {\nreturn x;\n}\n:history\n"
+	if !strings.Contains(out, expected) {
+		t.Errorf("history wrong. expected to contain %q, got=%q", expected, out)
+	}
+}
@@ -0,0 +1,360 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/evaluator"
+	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/parser"
+	"github.com/OisinA/Azula/token"
+	"github.com/OisinA/Azula/typecheck"
+)
+
+const PROMPT = ">> "
+
+// CONTINUE_PROMPT is shown while an entry has brackets left to close
+const CONTINUE_PROMPT = ".. "
+
+// HISTORY_FILE is kept in the user's home directory, with every line entered
+const HISTORY_FILE = ".azula_history"
+
+// session is a run of the repl, whose entries share one environment, and one checker
+// that knows the types of what they've defined
+type session struct {
+	reader  *bufio.Reader
+	out     io.Writer
+	env     *object.Environment
+	checker *typecheck.Checker
+	history string // the file lines entered are added to, or "" to keep none
+	// editor reads lines when the session is on a terminal, letting them be edited
+	editor *editor
+}
+
+func newSession(in io.Reader, out io.Writer, history string) *session {
+	s := &session{reader: bufio.NewReader(in), out: out, history: history}
+	s.reset()
+	return s
+}
+
+// reset starts a fresh environment, forgetting everything defined so far
+func (s *session) reset() {
+	s.env = object.NewEnvironment()
+	s.env.SetContext(object.NewContext(s.reader, s.out, s.out))
+	s.checker = typecheck.New()
+}
+
+func (s *session) run() {
+	for {
+		entry, ok := s.read()
+		if !ok {
+			return
+		}
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		s.remember(entry)
+
+		if entry == "exit" {
+			entry = ":quit"
+		}
+		if strings.HasPrefix(entry, ":") {
+			if !s.command(entry) {
+				return
+			}
+			continue
+		}
+		s.eval(entry, "", true)
+	}
+}
+
+// read reads an entry, carrying on over more lines while it has brackets left to close.
+// Ctrl-c in the line editor gives up on the entry, giving an empty one.
+func (s *session) read() (string, bool) {
+	prompt := PROMPT
+	lines := []string{}
+	for {
+		line, err := s.readLine(prompt)
+		if err == errInterrupted {
+			return "", true
+		}
+		if err != nil && line == "" {
+			// what has been entered so far still runs when the input ends
+			return strings.Join(lines, "\n"), len(lines) > 0
+		}
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+		entry := strings.Join(lines, "\n")
+		if depth(entry) <= 0 {
+			return entry, true
+		}
+		prompt = CONTINUE_PROMPT
+	}
+}
+
+// readLine shows the prompt and reads a line, with the line editor if there is one
+func (s *session) readLine(prompt string) (string, error) {
+	if s.editor != nil {
+		return s.editor.readLine(prompt)
+	}
+	io.WriteString(s.out, prompt)
+	return s.reader.ReadString('\n')
+}
+
+// depth gives how many brackets of any kind are left open in the source. Brackets
+// in strings don't count, as the lexer reads them as part of the string, but the ${ of
+// an interpolation does, as does a raw string, which can span lines.
+func depth(source string) int {
+	n := 0
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE, token.LBRACKET, token.LPAREN, token.STRING_HEAD:
+			n++
+		case token.RBRACE, token.RBRACKET, token.RPAREN, token.STRING_TAIL:
+			n--
+		case token.INVALID:
+			if tok.Literal == lexer.UnterminatedRawString {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// useTerminal has the session read lines with the line editor, putting the terminal in
+// raw mode while it does. Lines from the history file can be brought back.
+func (s *session) useTerminal(f *os.File) {
+	history := []string{}
+	if s.history != "" {
+		if dat, err := ioutil.ReadFile(s.history); err == nil {
+			for _, line := range strings.Split(string(dat), "\n") {
+				if strings.TrimSpace(line) != "" {
+					history = append(history, line)
+				}
+			}
+		}
+	}
+	s.editor = newEditor(s.reader, s.out, history, func() (func(), error) {
+		return rawMode(f.Fd())
+	})
+}
+
+// remember adds an entry to the history file. Failing to is not worth stopping for.
+func (s *session) remember(entry string) {
+	if s.history == "" {
+		return
+	}
+	f, err := os.OpenFile(s.history, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	io.WriteString(f, entry+"\n")
+}
+
+// eval runs an entry, or a file loaded into the session, printing the error it raises
+// or, if echo is set, the value it gives. Like a program, it isn't run if it fails
+// type checking.
+func (s *session) eval(source string, filename string, echo bool) {
+	p := parser.New(lexer.NewWithFilename(source, filename))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, source, p.ErrorList())
+		return
+	}
+
+	if errors := s.checker.Extend(program); len(errors) != 0 {
+		s.printTypeErrors(errors, source, filename)
+		return
+	}
+
+	result := evaluator.Eval(program, s.env)
+	if err, ok := result.(*object.Error); ok {
+		s.printError(err, source, filename)
+		return
+	}
+	if echo && echoes(program) && result != nil && result != evaluator.NULL {
+		io.WriteString(s.out, result.Inspect()+"\n")
+	}
+}
+
+// printError prints an error raised by an entry or a file. The source of a file can
+// be read again, but earlier entries' is gone, so only the latest entry's is shown.
+func (s *session) printError(err *object.Error, source string, filename string) {
+	io.WriteString(s.env.Context().Err, FormatTrace(err, func(pos token.Position) string {
+		if pos.Filename == filename && (filename != "" || len(err.Stack) == 0) {
+			return source
+		}
+		if pos.Filename != "" {
+			if dat, err := ioutil.ReadFile(pos.Filename); err == nil {
+				return string(dat)
+			}
+		}
+		return ""
+	}))
+}
+
+// printTypeErrors prints the type errors found in an entry or a file, along with the
+// source of the file each is in
+func (s *session) printTypeErrors(errors []*typecheck.Error, source string, filename string) {
+	io.WriteString(s.out, "type errors:\n")
+	for _, err := range errors {
+		src := source
+		if err.Pos.Filename != filename {
+			src = ""
+			if dat, e := ioutil.ReadFile(err.Pos.Filename); e == nil {
+				src = string(dat)
+			}
+		}
+		io.WriteString(s.out, FormatError(src, err.Pos, err.Message))
+	}
+}
+
+// echoes reports whether the value of a program is worth printing, which it is when
+// it ends in an expression that isn't a definition
+func echoes(program *ast.Program) bool {
+	if len(program.Statements) == 0 {
+		return false
+	}
+	stmt, ok := program.Statements[len(program.Statements)-1].(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	switch exp := stmt.Expression.(type) {
+	case *ast.FunctionLiteral:
+		// an anonymous function is a value, rather than a definition
+		return exp.Name == nil
+	case *ast.ClassLiteral, *ast.InterfaceLiteral:
+		return false
+	}
+	return true
+}
+
+type command struct {
+	usage string
+	help  string
+	run   func(s *session, arg string) bool
+}
+
+// commands are run by entering their name after a colon, and give false to end the
+// session. They are filled in by init, as :help lists them.
+var commands map[string]command
+
+// commandOrder is the order :help lists the commands in
+var commandOrder = []string{"load", "env", "type", "reset", "history", "help", "quit"}
+
+func init() {
+	commands = map[string]command{
+		"load":    {":load file.azl", "run a file in the session", (*session).load},
+		"env":     {":env", "list everything defined", (*session).listEnv},
+		"type":    {":type expr", "give the type of an expression", (*session).typeOf},
+		"reset":   {":reset", "forget everything defined", (*session).resetCommand},
+		"history": {":history", "show the lines entered, from every session", (*session).showHistory},
+		"help":    {":help", "list the commands", (*session).help},
+		"quit":    {":quit", "end the session", (*session).quit},
+	}
+}
+
+func (s *session) command(entry string) bool {
+	name, arg := entry[1:], ""
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name, arg = name[:i], strings.TrimSpace(name[i:])
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "unknown command :%s, try :help\n", name)
+		return true
+	}
+	return cmd.run(s, arg)
+}
+
+func (s *session) load(filename string) bool {
+	if filename == "" {
+		io.WriteString(s.out, "usage: :load file.azl\n")
+		return true
+	}
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(s.out, "couldn't read file %s\n", filename)
+		return true
+	}
+	s.eval(string(dat), filename, false)
+	return true
+}
+
+func (s *session) listEnv(string) bool {
+	for _, name := range s.env.Names() {
+		val, _ := s.env.GetLocal(name)
+		switch val.(type) {
+		case *object.Function, *object.Class, *object.Interface, *object.Module:
+			// these inspect as their whole definition
+			fmt.Fprintf(s.out, "%s: %s\n", name, evaluator.TypeOf(val))
+		default:
+			fmt.Fprintf(s.out, "%s: %s = %s\n", name, evaluator.TypeOf(val), val.Inspect())
+		}
+	}
+	return true
+}
+
+// typeOf works out the type of an expression without running it, so it has no
+// effects on the session
+func (s *session) typeOf(expr string) bool {
+	if expr == "" {
+		io.WriteString(s.out, "usage: :type expr\n")
+		return true
+	}
+	// a statement such as x++ needs its semicolon, which isn't worth making anyone type
+	if !strings.HasSuffix(expr, ";") && !strings.HasSuffix(expr, "}") {
+		expr += ";"
+	}
+	p := parser.New(lexer.New(expr))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, expr, p.ErrorList())
+		return true
+	}
+	t, errors := s.checker.TypeOf(program)
+	if len(errors) != 0 {
+		s.printTypeErrors(errors, expr, "")
+		return true
+	}
+	io.WriteString(s.out, t.String()+"\n")
+	return true
+}
+
+func (s *session) resetCommand(string) bool {
+	s.reset()
+	io.WriteString(s.out, "environment reset\n")
+	return true
+}
+
+func (s *session) showHistory(string) bool {
+	if s.history == "" {
+		return true
+	}
+	dat, err := ioutil.ReadFile(s.history)
+	if err != nil {
+		return true
+	}
+	io.WriteString(s.out, string(dat))
+	return true
+}
+
+func (s *session) help(string) bool {
+	for _, name := range commandOrder {
+		fmt.Fprintf(s.out, "  %-16s %s\n", commands[name].usage, commands[name].help)
+	}
+	return true
+}
+
+func (s *session) quit(string) bool {
+	io.WriteString(s.out, "Goodbye :)\n")
+	return false
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package repl
+
+import "errors"
+
+// the line editor is only used where raw mode is supported, elsewhere lines are read
+// as they're entered
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func rawMode(fd uintptr) (func(), error) {
+	return nil, errors.New("raw mode isn't supported")
+}
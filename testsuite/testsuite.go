@@ -0,0 +1,532 @@
+// Package testsuite holds the cases every backend has to agree on. The evaluator and
+// the vm both run them, so a program gives the same result whichever one runs it.
+package testsuite
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/OisinA/Azula/object"
+)
+
+// Error expects running the input to give an error with this message
+type Error string
+
+// Inspect expects the result of running the input to inspect as this
+type Inspect string
+
+// Case is an input program, and what it should give. Expected is an int, float64,
+// bool or string for a value of that type, an Error or Inspect, or nil for null.
+type Case struct {
+	Input    string
+	Expected interface{}
+}
+
+var Cases = []Case{
+	// integers
+	{"5", 5},
+	{"-10", -10},
+	{"5 + 5 + 5 + 5 + 5", 25},
+	{"(5 + 10) * 2 + 4", 34},
+	{"50 / 2 * 2 + 10 - 5", 55},
+	{"17 % 5", 2},
+	{"-17 % 5", -2},
+	{"1 + 17 % 5 * 2", 5},
+	{"12 & 10", 8},
+	{"12 | 10", 14},
+	{"12 ^ 10", 6},
+	{"1 << 10", 1024},
+	{"-16 >> 2", -4},
+	{"~5", -6},
+	{"6 & 3 == 2", true},
+	{"1 << -1", Error("negative shift count: -1")},
+	{"1 << 62", 4611686018427387904},
+	{"-1 << 63", -9223372036854775807 - 1},
+	{"1 << 64", Error("integer overflow: 1 << 64")},
+	{"4611686018427387904 << 1", Error("integer overflow: 4611686018427387904 << 1")},
+	{"-3 << 62", Error("integer overflow: -3 << 62")},
+	{"0 << 100", 0},
+	{"-8 >> 100", -1},
+	{"~true", Error("unknown operator: ~BOOLEAN")},
+	{"9223372036854775807 + 1", Error("integer overflow: 9223372036854775807 + 1")},
+	{"-9223372036854775807 - 2", Error("integer overflow: -9223372036854775807 - 2")},
+	{"4611686018427387904 * 2", Error("integer overflow: 4611686018427387904 * 2")},
+	{"-9223372036854775807 - 1", -9223372036854775808},
+	{"-(-9223372036854775807 - 1)", Error("integer overflow: -(-9223372036854775808)")},
+	{"(-9223372036854775807 - 1) / -1", Error("integer overflow: -9223372036854775808 / -1")},
+	{"1 / 0", Error("division by zero")},
+	{"1 % 0", Error("modulo by zero")},
+	{`string s = ""; try { 9223372036854775807 * 9223372036854775807; } catch (OverflowError e) { s = e.kind; } s;`, "OverflowError"},
+	{`string s = ""; try { 5 / 0; } catch (ZeroDivisionError e) { s = e.kind; } s;`, "ZeroDivisionError"},
+	{`int e = 5; try { throw "x"; } catch (e) { } e + 1;`, 6},
+	{`int e = 5; try { throw "x"; } catch (e) { e.message; } e;`, 5},
+	{`func f(): string { int n = 1; try { throw "x"; } catch (e) { int m = 2; return e.message + "${n + m}"; } return ""; } f();`, "x3"},
+
+	// booleans
+	{"true", true},
+	{"1 < 2", true},
+	{"1 > 2", false},
+	{"1 == 1", true},
+	{"true == true", true},
+	{"false == true", false},
+	{"true != true", false},
+	{"(1 < 2) == true", true},
+	{`"a" == "a"`, true},
+	{`"a" != "a"`, false},
+	{`"a" != "b"`, true},
+	{"1.5 != 1.5", false},
+	{"to_bigint(5) != to_bigint(5)", false},
+	{"array(int) a = [1]; a == a", true},
+	{"[1] == [1]", false},
+	{"array(int) a = [1]; a != a", false},
+	{`"1" != 1`, true},
+	{"1 != 1", false},
+	{"!true", false},
+	{"!!true", true},
+	{"!5", false},
+	{"1 <= 1", true},
+	{"2 <= 1", false},
+	{"1 >= 2", false},
+	{"2.5 >= 2.5", true},
+	{"int x = 5; x > 3 && x < 10", true},
+	{"int x = 5; x < 3 || x > 10", false},
+	{"true && false || true", true},
+	{"false || false", false},
+	// the right side isn't run when the left decides the result
+	{"false && [1][5] == 1", false},
+	{"true || [1][5] == 1", true},
+	{"true && [1][5] == 1", Error("index out of bounds")},
+
+	// conditionals
+	{"if(true) { 10 }", 10},
+	{"if(false) { 10 }", nil},
+	{"if(1 > 2) { 10 } else { 20 }", 20},
+
+	// returns
+	{"return 10;", 10},
+	{"return 10; 20;", 10},
+	{"return 2 * 5; 5;", 10},
+
+	// errors
+	{"-true", Error("unknown operator: -BOOLEAN")},
+	{"foobar", Error("identifier not found: foobar")},
+	{`"Hello" - "World"`, Error("unknown operator: STRING - STRING")},
+	{"int x = true;", Error("trying to assign bool to int: x")},
+	{"int x = 5; x = true;", Error("can't assign value of type int to variable of type bool")},
+	{"y = 5;", Error("can't reassign value to non-existent variable 'y'")},
+	{"func f(): int { return true; } f();", Error("function f returned bool, not int")},
+	{"func f(int n): int { if(n > 0) { return n; } } f(0);", Error("function f returned void, not int")},
+	{"array(int) e = []; append(e, 1)", Inspect("[1]")},
+	{"func f(): array(string) { return []; } type(f()) + len(f());", "array0"},
+	{"array(int) e = []; array(string) s = e;", Error("trying to assign array int to array string: s")},
+	{"func g(): void { 1; } type(g());", "void"},
+	{"for(x in 5) { x; }", Error("iterator must be an array")},
+
+	// variables
+	{"int x = 5; x;", 5},
+	{"int x = 5 * 25; x;", 125},
+	{"int x = 5; x = 10; x;", 10},
+	{"int a = 5; int b = a; int c = a + b + 5; c;", 15},
+
+	// functions
+	{"func identity(int x): array(int) { return range(x); }; len(identity(5));", 5},
+	{"func double(int x): int { return x * 2; }; double(5);", 10},
+	{"func add(int a, int b): int { a + b; }; add(2, add(3, 4));", 9},
+	{"func fib(int n): int { if(n < 2) { return n; } return fib(n - 1) + fib(n - 2); } fib(15);", 610},
+	{"func early(): int { double(2); } func double(int x): int { x * 2; } early();", 4},
+	{"func outer(int x): int { func inner(int y): int { x + y; } inner(2); } outer(3);", 5},
+	{"func nothing(): void { 5; } nothing();", nil},
+
+	// anonymous functions and closures, which share the variables they use with the
+	// function they were made in
+	{"func(int x): int { return x * 2; }(21);", 42},
+	{"func apply(func(int): int f, int x): int { return f(x); } apply(func(int x): int { return x + 1; }, 1);", 2},
+	{"func(int, int): int add = func(int a, int b): int { return a + b; }; add(2, 3);", 5},
+	{"func adder(int k): func(int): int { return func(int x): int { return x + k; }; } adder(10)(5);", 15},
+	{"func counter(): func(): int { int n = 0; return func(): int { n += 1; return n; }; } func(): int c = counter(); c(); c(); c() * 10 + counter()();", 31},
+	{"func f(): int { int x = 1; func(): int get = func(): int { return x; }; x = 5; return get(); } f();", 5},
+	{"func f(): int { int n = 0; func(): void inc = func(): void { n++; }; inc(); inc(); return n; } f();", 2},
+	{"func f(): int { int a = 1; func(): func(): int mk = func(): func(): int { return func(): int { a *= 2; return a; }; }; mk()(); mk()(); return a; } f();", 4},
+	{`func(): int saved = func(): int { return 0; }; func f(): int { int v = 7; saved = func(): int { v++; return v; }; throw "x"; } try { f(); } catch (e) { } func g(int a, int b): int { return a + b; } g(1, 2); saved(); saved();`, 9},
+	{"func(array(int)): int size = len; size([1, 2, 3]);", 3},
+	{"func(int): int f = func(int x): bool { return true; };", Error("trying to assign func(int): bool to func(int): int: f")},
+	{"func f(): func(): int { return func(): bool { return true; }; } f();", Error("function f returned func(): bool, not func(): int")},
+	{"func(): int f = func(): int { return true; }; f();", Error("function <lambda> returned bool, not int")},
+	{"class A() { } class B() extends A() { } func(B): int f = func(A a): int { return 1; }; f(B());", 1},
+	{"class A() { } class B() extends A() { } func(): A f = func(): B { return B(); }; f();", Inspect("B()")},
+	{"func(int): int f = func(string s): int { return 1; };", Error("trying to assign func(string): int to func(int): int: f")},
+	{"func(): int f = 5;", Error("trying to assign int to func(): int: f")},
+
+	// strings
+	{`"Hello World"`, "Hello World"},
+	{`"Hello" + " " + "World!" + 17`, "Hello World!17"},
+	{`len("four")`, 4},
+	{`len("")`, 0},
+	{`len("héllo")`, 5},
+	{`"héllo"[1]`, "é"},
+	{`"héllo"[-1]`, "o"},
+	{`"abc"[3]`, Error("index out of bounds")},
+	{`"héllo"[1:3]`, "él"},
+	{`"héllo"[:2] + "héllo"[3:]`, "hélo"},
+	{`reverse("héllo")`, "olléh"},
+	{`index_of("héllo", "l") + index_of("héllo", "x")`, 1},
+	{`"abc"[2:1]`, Error("slice bounds out of range")},
+	{`string s = "ab"; s[0] = "c";`, Error("can't assign to a character of a string")},
+	{`join(split("a,b,,c", ","), "-")`, "a-b--c"},
+	{`len(split("héllo", ""))`, 5},
+	{`join([1], "")`, Error("can only join strings, got INTEGER")},
+	{`"[" + trim("  hi  ") + "]"`, "[hi]"},
+	{`upper("héllo") + lower("ÀB")`, "HÉLLOàb"},
+	{`contains("héllo", "ll") && starts_with("héllo", "hé") && !ends_with("héllo", "x")`, true},
+	{`replace("a-b-c", "-", "+")`, "a+b+c"},
+	{`find("héllo", "l")`, 2},
+	{`find("héllo", "z")`, -1},
+	{`repeat("ab", 3)`, "ababab"},
+	{`repeat("ab", -1)`, Error("can't repeat a string -1 times")},
+	{`substring("héllo", 1, 3) + substring("héllo", -2)`, "éllo"},
+	{`pad_left("7", 3, "0") + pad_right("é", 2) + pad_left("long", 2)`, "007é long"},
+	{`pad_left("7", 3, "00")`, Error("padding given to 'pad_left' must be one character, got 2 characters")},
+	{`chr(233) + chr(ord("a") + 1)`, "éb"},
+	{`ord("é")`, 233},
+	{`ord("ab")`, Error("argument to 'ord' must be one character, got 2 characters")},
+	{`chr(-1)`, Error("-1 is not a valid code point")},
+	{`upper(1)`, Error("argument to 'upper' must be string, got INTEGER")},
+	{`string s = ""; try { chr(55296); } catch (ValueError e) { s = e.message; } s;`, "55296 is not a valid code point"},
+	{`"a\tb\n\"c\"\\"`, "a\tb\n\"c\"\\"},
+	{`len("\u{e9}\u{1F600}")`, 2},
+	{`string café = "crème"; int größe = 2; class Über(string naïve) { } café + größe + Über("ü").naïve;`, "crème2ü"},
+	{"`raw ${x} \\n\nline`", "raw ${x} \\n\nline"},
+	{`string name = "Oisín"; int age = 19; "hello ${name}, you are ${age + 1}";`, "hello Oisín, you are 20"},
+	{`int a = 1; "${a}${a + 1}"`, "12"},
+	{`"${[1, 2]} ${ {"k": true} } ${"in ${"ner"}"} \${x}"`, "[1, 2] {k: true} in ner ${x}"},
+	{`func f(int x): string { return "<${x}>"; } f(3) + f(4);`, "<3><4>"},
+	{`"${1 / 0}"`, Error("division by zero")},
+
+	// arrays
+	{"[1, 2 * 2, 3 + 3]", Inspect("[1, 4, 6]")},
+	{"[1, 2, 3, 4][0]", 1},
+	{"array(int) x = [400, 1000]; x[1];", 1000},
+	{"[1, true]", Error("trying to assign bool to array of int")},
+	{"len(append([1, 2], 3))", 3},
+	{"array(int) a = range(3); array(int) b = append(a, 9); b[0] = 7; append(a, 8); a;", Inspect("[0, 1, 2]")},
+	{"array(int) a = [1, 2, 3]; array(int) b = append(a, 9); b[0] = 7; append(a, 8); b;", Inspect("[7, 2, 3, 9]")},
+
+	// collection builtins, which call functions from the program
+	{"func double(int x): int { return x * 2; } map([1, 2, 3], double);", Inspect("[2, 4, 6]")},
+	{`array(string) s = map([1, 2], func(int x): string { return "n" + x; }); s;`, Inspect("[n1, n2]")},
+	{`array(string) s = map([1, 2], func(int x): int { return x; });`, Error("trying to assign array int to array string: s")},
+	{"array(int) xs = map([], func(int x): int { return x; }); len(xs);", 0},
+	{"filter([1, 2, 3, 4], func(int x): bool { return x % 2 == 0; })", Inspect("[2, 4]")},
+	{"array(int) e = filter([], func(string s): bool { return true; }); e", Error("trying to assign array string to array int: e")},
+	{"array(array(int)) e = map([], func(int x): array(int) { return [x]; }); append(e, [1])", Inspect("[[1]]")},
+	{"filter([1, 2], func(int x): int { return x; })", Error("function given to 'filter' must return bool, got int")},
+	{"reduce([1, 2, 3, 4], func(int a, int b): int { return a * b; }, 1)", 24},
+	{"sort([3, 1, 2])", Inspect("[1, 2, 3]")},
+	{`sort(["b", "c", "a"])`, Inspect("[a, b, c]")},
+	{"sort([3, 1, 2], func(int a, int b): bool { return a > b; })", Inspect("[3, 2, 1]")},
+	{"sort([[2], [1]], func(array(int) a, array(int) b): bool { return a[0] < b[0]; })", Inspect("[[1], [2]]")},
+	{"sort([[2], [1]])", Error("can't sort array without a function to compare them")},
+	{"reverse([1, 2, 3])", Inspect("[3, 2, 1]")},
+	{"slice([1, 2, 3, 4], 1, 3)", Inspect("[2, 3]")},
+	{"slice([1, 2, 3, 4], -2)", Inspect("[3, 4]")},
+	{"slice([1, 2, 3], 2, 5)", Inspect("[3]")},
+	{`"héllo"[10:] + "|" + "héllo"[-10:2] + "|" + "héllo"[-2:]`, "|hé|lo"},
+	{"[1, 2, 3][-5:10]", Inspect("[1, 2, 3]")},
+	{"[1, 2, 3, 4][1:3]", Inspect("[2, 3]")},
+	{"array(int) xs = [1, 2, 3][:-1]; xs;", Inspect("[1, 2]")},
+	{`[1, 2][0:"a"]`, Error("slice bounds must be int, got STRING")},
+	{"5[1:]", Error("slice operator not supported: INTEGER")},
+	{"index_of([1, 2, 3], 3)", 2},
+	{`index_of(["a"], "b")`, -1},
+	{"index_of([false, true], true)", 1},
+	{"any([1, 2], func(int x): bool { return x > 1; })", true},
+	{"all([1, 2], func(int x): bool { return x > 1; })", false},
+	{"zip([1, 2, 3], [4, 5])", Inspect("[[1, 4], [2, 5]]")},
+	{"array(array(int)) ps = zip([1, 2], [3, 4]); ps[1]", Inspect("[2, 4]")},
+	{"array(func(int): int) fs = [func(int x): int { return x * 2; }]; fs[0](4)", 8},
+	{"array(array(string)) ps = zip([1], [2]); ps", Error("trying to assign array array to array array(string): ps")},
+	{`zip([1], ["a"])`, Error("arguments to 'zip' must be arrays of the same type, got array(int) and array(string)")},
+	{`hashmap(int, string) m = enumerate(["a", "b"]); m[1];`, "b"},
+	{"int total = 0; map([1, 2, 3], func(int x): int { total += x; return x; }); total;", 6},
+	{"map([1, 2], func(int x): int { return x / 0; })", Error("division by zero")},
+	{`string s = ""; try { map([1], func(int x): int { throw "boom"; }); } catch (e) { s = e.message; } s;`, "boom"},
+	{"map(1, len)", Error("first argument to 'map' must be array, got INTEGER")},
+
+	// for loops
+	{"int i = 0; for(x in [1, 2, 3, 4]) { x; }", 4},
+	{"int total = 0; for(x in range(5)) { total = total + x; } total;", 10},
+
+	// assignment
+	{"int x = 5; x += 3; x", 8},
+	{"int x = 5; x -= 3; x *= 4; x /= 2; x %= 3; x", 1},
+	{"float f = 1.5; f *= 2.0; f", 3.0},
+	{`string s = "a"; s += "b"; s += 1; s`, "ab1"},
+	{"int i = 0; i++; i++; i--; i", 1},
+	{"int total = 0; for(x in range(5)) { total += x; } total;", 10},
+	{"array(int) xs = [1, 2, 3]; xs[0] = 10; xs[-1] += 5; xs", Inspect("[10, 2, 8]")},
+	{"class Row(array(int) cells) { } Row r = Row([1, 2]); r.cells[1] *= 10; r.cells[1]", 20},
+	{`class A() { } class B() extends A() { } func make(): A { return B(); } hashmap(string, A) m = {"k": make()}; len(m);`, 1},
+	{"array(int) xs = [1, 2]; array(int) ys = xs; ys[0] = 5; xs[0]", 5},
+	{`hashmap(string, int) ages = {"a": 1}; ages["a"] += 1; ages["b"] = 7; ages`, Inspect(`{a: 2, b: 7}`)},
+	{`hashmap(string, int) ages = {}; ages["a"] = 1; ages["a"]`, 1},
+	{"class Point(int x, int y) { } Point p = Point(1, 2); p.x += 5; p.y++; p.x + p.y;", 9},
+	{"class Counter(int n) { func inc(): void { this.n += 1; n++; } } Counter c = Counter(0); c.inc(); c.n;", 2},
+	{"int x = 5; x -= true;", Error("type mismatch: INTEGER - BOOLEAN")},
+	{"int x = 5; x = x + 1.5;", Error("type mismatch: INTEGER + FLOAT")},
+	{"y += 5;", Error("can't reassign value to non-existent variable 'y'")},
+	{"array(int) xs = [1]; xs[1] = 2;", Error("index out of bounds")},
+	{"array(int) xs = [1]; xs[0] = true;", Error("can't assign value of type int to variable of type bool")},
+	{`array(int) xs = [1]; xs["a"] = 1;`, Error("array index must be int, not string")},
+	{`hashmap(string, int) m = {"a": 1}; m[1] = 2;`, Error("can't use int as key of hashmap(string, int)")},
+	{`hashmap(string, int) m = {"a": 1}; m["b"] = "c";`, Error("trying to add string: string to hashmap(string, int)")},
+	{`hashmap(string, int) m = {"a": 1}; m["b"] += 1;`, Error("key not found: b")},
+	{"int x = 1; x[0] = 2;", Error("index operator not supported: INTEGER")},
+
+	// classes
+	{"class TestClass(int x) { func getx(): int { return x; } } TestClass c = TestClass(5); c.getx();", 5},
+	{"class Counter(int start) { int count = start * 2; func get(): int { count; } } Counter c = Counter(4); c.get();", 8},
+	{"class Point(int x, int y) { } Point p = Point(1, 2); p.y;", 2},
+	{"class Point(int x, int y) { } Point p = Point(1, 2); p.x = 5; p.x + p.y;", 7},
+	{"class Point(int x, int y) { } Point(1, 2)", Inspect("Point(x: 1, y: 2)")},
+	{"class Box(int v) { int twice = v * 2; func get(): int { v; } } Box(3)", Inspect("Box(v: 3, twice: 6)")},
+	{"class Counter(int n) { func inc(): void { this.n = this.n + 1; } } Counter c = Counter(0); c.inc(); c.inc(); c.n;", 2},
+	{"class Node(int v) { func self(): Node { this; } } Node n = Node(4); n.self().v;", 4},
+	{"int scale = 3; class Box(int v) { func scaled(): int { v * scale; } } Box b = Box(2); b.scaled();", 6},
+	{"func double(int x): int { x * 2; } class Box(int v) { func get(): int { double(v); } } Box(5).get();", 10},
+	{"class Point(int x, int y) { } Point(1);", Error("wrong number of arguments to Point. got=1, want=2")},
+	{"class Point(int x) { } Point p = Point(1); p.z;", Error("class Point has no field z")},
+	{"class Point(int x) { } Point p = Point(1); p.area();", Error("class Point has no method area")},
+	{"class Point(int x) { } Point p = Point(1); p.x = true;", Error("can't assign value of type int to variable of type bool")},
+	{"int x = 5; x.y;", Error("can't get field y of INTEGER, it is not an object")},
+	{"this;", Error("this outside of a class")},
+	{"class A(int x) { } class B(int x) { } A a = B(1);", Error("trying to assign B to A: a")},
+
+	// inheritance
+	{"class A(int x) { func get(): int { x; } } class B(int x) extends A { } B b = B(3); b.get();", 3},
+	{"class A() { func name(): string { \"A\"; } } class B() extends A { func name(): string { \"B\"; } } A a = B(); a.name();", "B"},
+	{"class A() { func name(): string { \"A\"; } } class B() extends A { func name(): string { \"B\" + super.name(); } } B().name();", "BA"},
+	{"class A() { func name(): string { \"A\"; } func greet(): string { \"hi \" + this.name(); } } class B() extends A { func name(): string { \"B\"; } } B().greet();", "hi B"},
+	{"class A(int x) { } class B(int y) extends A(y * 2) { func sum(): int { x + y; } } B(5).sum();", 15},
+	{"class A() { int n = 1; func bump(): void { n = n + 1; } } class B() extends A { func get(): int { n; } } B b = B(); b.bump(); b.get();", 2},
+	{"class A(int x) { } class B(int y) extends A(y) { } B(1)", Inspect("B(y: 1, x: 1)")},
+	{"class A() { } class B() extends A { } class C() extends B { } A a = C(); 1;", 1},
+	{"class A() { } class B() extends A { } func make(): A { B(); } make()", Inspect("B()")},
+	{"class A(int x) { } class B(int x) extends A(1, 2) { } B(1);", Error("wrong number of arguments to A. got=2, want=1")},
+	{"int A = 1; class B() extends A { } B();", Error("A is not a class")},
+	{"class A() { func f(): int { super.f(); } } A().f();", Error("super outside of a subclass")},
+
+	// interfaces
+	{"interface Shape { func area(): int; } class Sq(int s) implements Shape { func area(): int { s * s; } } Shape s = Sq(3); s.area();", 9},
+	{"interface Shape { func area(): int; } class Sq(int s) implements Shape { func area(): int { s * s; } } func twice(Shape s): int { s.area() * 2; } twice(Sq(2));", 8},
+	{"interface Shape { func area(): int; } class Sq(int s) implements Shape { func area(): int { s; } } class Re(int w) implements Shape { func area(): int { w; } } Shape s = Sq(1); s = Re(2); s.area();", 2},
+	{"interface Shape { func area(): int; } class A(int s) { func area(): int { s; } } class B(int s) extends A implements Shape { } func make(): Shape { B(4); } make().area();", 4},
+	{"interface Shape { func area(): int; } class Sq(int s) implements Shape { }", Error("class Sq doesn't implement Shape: missing method area")},
+	{"interface Shape { func area(): int; } class Sq(int s) implements Shape { func area(): string { \"a\"; } }", Error("class Sq doesn't implement Shape: method area is func(): string, not func(): int")},
+	{"interface Shape { func area(): int; } class Sq(int s) { } Shape s = Sq(1);", Error("trying to assign Sq to Shape: s")},
+	{"int n = 1; class Sq() implements n { }", Error("n is not an interface")},
+
+	// modules, which are found relative to the package running the suite
+	{`import "../testsuite/testdata/counter.azl" as c; c.next(); c.next();`, 2},
+	{`import "../testsuite/testdata/counter.azl"; counter.next();`, 1},
+	{`import "../testsuite/testdata/counter" as a; import "../testsuite/testdata/counter.azl" as b; a.next(); b.next();`, 2},
+	{`import "../testsuite/testdata/counter.azl" as c; c.next(); c.count;`, 1},
+	{`import "../testsuite/testdata/counter.azl" as c; c._secret;`, Error("module counter doesn't export _secret")},
+	{`import "../testsuite/testdata/counter.azl" as c; c.missing();`, Error("module counter doesn't export missing")},
+	{`import "../testsuite/testdata/counter.azl" as c; next();`, Error("identifier not found: next")},
+	{`import "../testsuite/testdata/counter.azl" as c; c.count = 1;`, Error("can't assign to count of module counter")},
+	{`import "../testsuite/testdata/cycle_a.azl" as a;`, Error("import cycle: cycle_a.azl -> cycle_b.azl -> cycle_a.azl")},
+	{`import "../testsuite/testdata/missing.azl" as m;`, Error("couldn't import file '../testsuite/testdata/missing.azl'")},
+
+	// floats
+	{"1.5 + 2.25", 3.75},
+	{"10.0 / 4.0", 2.5},
+	{"-2.5", -2.5},
+	{"to_int(3.99)", 3},
+	{"to_int(-3.99)", -3},
+	{"to_int(1e300)", Error("float 1e+300 is too large to convert to int")},
+	{"to_int(-1e300)", Error("float -1e+300 is too large to convert to int")},
+	{"to_int(0.0 / 0.0)", Error("couldn't convert NaN to int")},
+	{"1.5 < 2.5", true},
+	{`"total: " + 2.5`, "total: 2.5"},
+	{"1 + 1.0", Error("type mismatch: INTEGER + FLOAT")},
+	{"1.0 / 0.0", Inspect("+Inf")},
+
+	// bigints
+	{"to_bigint(9223372036854775807) + to_bigint(1)", Inspect("9223372036854775808")},
+	{`to_bigint("123456789012345678901234567890") * to_bigint(10)`, Inspect("1234567890123456789012345678900")},
+	{"to_bigint(1) << to_bigint(100)", Inspect("1267650600228229401496703205376")},
+	{"to_bigint(-17) / to_bigint(5)", Inspect("-3")},
+	{"to_bigint(-17) % to_bigint(5)", Inspect("-2")},
+	{"-to_bigint(5)", Inspect("-5")},
+	{"~to_bigint(5)", Inspect("-6")},
+	{"to_bigint(3.9)", Inspect("3")},
+	{"to_bigint(5) == to_bigint(5)", true},
+	{"to_bigint(5) < to_bigint(6)", true},
+	{"bigint b = to_bigint(7); b *= to_bigint(6); to_int(b);", 42},
+	{`hashmap(bigint, string) h = {to_bigint(1): "one"}; h[to_bigint(1)];`, "one"},
+	{"to_bigint(1) + 1", Error("type mismatch: BIGINT + INTEGER")},
+	{"to_bigint(1) / to_bigint(0)", Error("division by zero")},
+	{"to_bigint(1) << to_bigint(-1)", Error("negative shift count: -1")},
+	{`to_bigint("abc")`, Error("couldn't convert 'abc' to bigint")},
+	{`to_int(to_bigint("9223372036854775808"))`, Error("bigint 9223372036854775808 is too large to convert to int")},
+
+	// hashmaps
+	{`hashmap(string, int) ages = {"one": 1, "two": 1 + 1}; ages;`, Inspect("{one: 1, two: 2}")},
+	{`{"foo": 5}["foo"]`, 5},
+	{`hashmap(int, int) m = {}; len(m);`, 0},
+	{`{"foo": 5}["bar"]`, Error("key not found: bar")},
+	{`{1: 5, "two": 6}`, Error("trying to add string: int to hashmap(int, int)")},
+	{`hashmap(string, int) m = {1: 5};`, Error("trying to assign hashmap(int, int) to hashmap(string, int): m")},
+	{`delete({"a": 1, "b": 2}, "a")`, Inspect("{b: 2}")},
+
+	// while loops
+	{"int i = 0; while(i < 5) { i = i + 1; } i;", 5},
+	{"int i = 0; while(true) { i = i + 1; if(i > 3) { break; } } i;", 4},
+	{"int i = 0; int odd = 0; while(i < 10) { i = i + 1; if(i / 2 * 2 == i) { continue; } odd = odd + 1; } odd;", 5},
+	{"int total = 0; for(x in range(10)) { if(x > 4) { break; } total = total + x; } total;", 10},
+	{"func find(): int { for(x in range(10)) { if(x == 3) { return x; } } return -1; } find();", 3},
+	{"int n = 0; for(x in range(3)) { for(y in range(3)) { if(y == 1) { break; } n = n + 1; } } n;", 3},
+	{"while(false) { 1; }", nil},
+	{"break;", Error("break outside of a loop")},
+	{"func f(): int { continue; } f();", Error("continue outside of a loop in function f")},
+
+	// exceptions
+	{`string s = ""; try { to_int("abc"); } catch (e) { s = e.kind; } s;`, "ConversionError"},
+	{`string s = ""; try { to_int("abc"); } catch (e) { s = e.message; } s;`, "couldn't convert 'abc' to int"},
+	{`string s = ""; try { [1, 2][5]; } catch (TypeError e) { s = "type"; } catch (IndexError e) { s = "index"; } s;`, "index"},
+	{`string s = ""; try { {"a": 1}["b"]; } catch (KeyError e) { s = e.message; } s;`, "key not found: b"},
+	{`string s = ""; func f(int a): int { return a; } try { f(1, 2); } catch (ArityError e) { s = e.message; } s;`, "wrong number of arguments to f. got=2, want=1"},
+	{`string s = ""; try { throw "oops"; } catch (e) { s = e.kind + ": " + e.message; } s;`, "Error: oops"},
+	{`string s = ""; try { throw error("ValueError", "bad"); } catch (ValueError e) { s = e.message; } s;`, "bad"},
+	{`string s = ""; try { throw 5; } catch (TypeError e) { s = e.message; } s;`, "can only throw a string or an error, not int"},
+	{`try { throw "uncaught"; } catch (IndexError e) { 1; }`, Error("uncaught")},
+	{`throw "oops";`, Error("oops")},
+	{`string s = ""; try { try { throw "inner"; } catch (e) { throw e; } } catch (e) { s = "outer " + e.message; } s;`, "outer inner"},
+	{`string s = ""; try { s = s + "a"; } catch (e) { s = s + "b"; } finally { s = s + "c"; } s;`, "ac"},
+	{`string s = ""; try { try { throw "x"; } finally { s = s + "f"; } } catch (e) { s = s + e.message; } s;`, "fx"},
+	{`string s = ""; try { try { throw "x"; } catch (e) { throw "y"; } finally { s = s + "f"; } } catch (e) { s = s + e.message; } s;`, "fy"},
+	{`int n = 0; func f(): int { try { return 1; } finally { n = 2; } } f() + n;`, 3},
+	{`func f(): int { try { return 1; } finally { return 2; } } f();`, 2},
+	{`func f(): int { try { throw "x"; } catch (e) { return 3; } return 4; } f();`, 3},
+	{`int n = 0; for(x in range(5)) { try { if(x == 2) { break; } } finally { n = n + 1; } } n;`, 3},
+	{`int n = 0; for(x in range(5)) { try { if(x == 2) { continue; } n = n + 10; } finally { n = n + 1; } } n;`, 45},
+	{`func f(): int { throw "x"; } func g(): int { return f(); } int n = 0; try { g(); } catch (e) { n = len(e.stack); } n;`, 3},
+	{`func f(): int { throw "x"; } int n = 0; for(x in range(3)) { try { f(); } catch (e) { n = n + 1; } } n;`, 3},
+	{`try { 5; } catch (e) { 6; }`, nil},
+
+	// call stacks, innermost call first
+	{`func f(): int { throw "x"; } func g(): int { return f(); } array(string) s = ["a"]; try { g(); } catch (e) { s = e.stack; } s;`, Inspect("[f at 1:17, g at 1:54, <main> at 1:92]")},
+	{`class A(int x) { int y = [1][x]; } class B() extends A(5) { } array(string) s = ["a"]; try { B(); } catch (e) { s = e.stack; } s;`, Inspect("[A at 1:29, B at 1:42, <main> at 1:95]")},
+	{`array(string) s = ["a"]; func f(): int { try { throw "x"; } catch (e) { s = e.stack; } return 1; } f(); s;`, Inspect("[f at 1:48, <main> at 1:101]")},
+	{`class P() { func m(): int { return [1][2]; } } P p = P(); array(string) s = ["a"]; try { p.m(); } catch (e) { s = e.stack; } s;`, Inspect("[m at 1:39, <main> at 1:93]")},
+	{`func h(): int { } array(string) s = ["a"]; try { h(); } catch (e) { s = e.stack; } s;`, Inspect("[h at 1:1, <main> at 1:51]")},
+	{`func h(): int { return "a"; } func g(): int { return h(); } array(string) s = ["a"]; try { g(); } catch (e) { s = e.stack; } s;`, Inspect("[h at 1:17, g at 1:55, <main> at 1:93]")},
+	{`array(string) s = ["a"]; try { throw "x"; } catch (e) { s = e.stack; } s;`, Inspect("[<main> at 1:32]")},
+	{`array(string) s = ["a"]; try { func(): int { throw "x"; }(); } catch (e) { s = e.stack; } s;`, Inspect("[<lambda> at 1:46, <main> at 1:58]")},
+
+	// recursion depth
+	{"func f(int n): int { return f(n + 1); } f(0);", Error("maximum recursion depth exceeded in f")},
+	{"class A() { A a = A(); } A();", Error("maximum recursion depth exceeded in A")},
+	{`string s = ""; func f(int n): int { return f(n + 1); } try { f(0); } catch (RecursionError e) { s = e.kind; } s;`, "RecursionError"},
+	{"func f(int n): int { if(n == 0) { return 0; } return 1 + f(n - 1); } f(900);", 900},
+	{`error("oops")`, Inspect("Error: oops")},
+	{`error("IndexError", "oops").kind`, "IndexError"},
+	{`error(1)`, Error("arguments to 'error' must be strings, got INTEGER")},
+	{`error("a").kind = "b";`, Error("can't assign to kind of an error")},
+}
+
+// Run runs every case through a backend, which gives the result of running an input
+func Run(t *testing.T, run func(input string) object.Object) {
+	for _, tt := range Cases {
+		testResult(t, tt.Input, run(tt.Input), tt.Expected)
+	}
+}
+
+// IOCase is an input program, what it is given to read, and what it should write
+type IOCase struct {
+	Input  string
+	Stdin  string
+	Output string
+}
+
+var IOCases = []IOCase{
+	{`print("hello");`, "", "hello\n"},
+	{`print(1); print([1, 2]); print(2.5);`, "", "1\n[1, 2]\n2.5\n"},
+	{`string name = input("name: "); print("hi " + name);`, "Ada\n", "name: hi Ada\n"},
+	// input is buffered once, so a second call sees the next line rather than nothing
+	{`string a = input(); string b = input(); print(b + a);`, "x\ny\n", "yx\n"},
+	{`string a = input(); print(a);`, "no newline", "no newline\n"},
+	{`func f(): void { print("in f"); } f(); print("after");`, "", "in f\nafter\n"},
+	{`try { print("a"); throw "x"; } catch (e) { print(e.message); } finally { print("b"); }`, "", "a\nx\nb\n"},
+	// the target of a compound assignment is only evaluated once
+	{`array(int) xs = [1, 2]; func at(int i): int { print("at " + i); return i; } xs[at(1)] += 5; print(xs[1]);`, "", "at 1\n7\n"},
+	{`class Box(int v) { } Box b = Box(1); func box(): Box { print("box"); return b; } box().v *= 3; print(b.v);`, "", "box\n3\n"},
+	{`func f(string s, bool b): bool { print(s); return b; } f("a", false) && f("b", true); f("c", true) || f("d", true); f("e", true) && f("f", false) || f("g", true);`, "", "a\nc\ne\nf\ng\n"},
+}
+
+// RunIO runs every IO case through a backend, which runs an input in the context given
+func RunIO(t *testing.T, run func(input string, ctx *object.Context) object.Object) {
+	for _, tt := range IOCases {
+		var out bytes.Buffer
+		ctx := object.NewContext(strings.NewReader(tt.Stdin), &out, &out)
+		if err, ok := run(tt.Input, ctx).(*object.Error); ok {
+			t.Errorf("%q: unexpected error %q", tt.Input, err.Message)
+			continue
+		}
+		if out.String() != tt.Output {
+			t.Errorf("%q: wrong output. got=%q, want=%q", tt.Input, out.String(), tt.Output)
+		}
+	}
+}
+
+func testResult(t *testing.T, input string, obj object.Object, expected interface{}) {
+	t.Helper()
+
+	if errObj, ok := obj.(*object.Error); ok {
+		if expected != Error(errObj.Message) {
+			t.Errorf("%q: unexpected error %q. want=%v", input, errObj.Message, expected)
+		}
+		return
+	}
+
+	switch expected := expected.(type) {
+	case int:
+		result, ok := obj.(*object.Integer)
+		if !ok || result.Value != int64(expected) {
+			t.Errorf("%q: wrong result. got=%s, want=%d", input, describe(obj), expected)
+		}
+	case float64:
+		result, ok := obj.(*object.Float)
+		if !ok || result.Value != expected {
+			t.Errorf("%q: wrong result. got=%s, want=%g", input, describe(obj), expected)
+		}
+	case bool:
+		result, ok := obj.(*object.Boolean)
+		if !ok || result.Value != expected {
+			t.Errorf("%q: wrong result. got=%s, want=%t", input, describe(obj), expected)
+		}
+	case string:
+		result, ok := obj.(*object.String)
+		if !ok || result.Value != expected {
+			t.Errorf("%q: wrong result. got=%s, want=%q", input, describe(obj), expected)
+		}
+	case Inspect:
+		if obj == nil || obj.Inspect() != string(expected) {
+			t.Errorf("%q: wrong result. got=%s, want=%s", input, describe(obj), expected)
+		}
+	case Error:
+		t.Errorf("%q: no error returned. got=%s, want=%q", input, describe(obj), expected)
+	case nil:
+		if obj == nil || obj.Type() != object.NULL_OBJ {
+			t.Errorf("%q: wrong result. got=%s, want=null", input, describe(obj))
+		}
+	}
+}
+
+func describe(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+	return string(obj.Type()) + " " + obj.Inspect()
+}
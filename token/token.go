@@ -1,22 +1,49 @@
 package token
 
+import (
+	"fmt"
+)
+
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 
 	IDENT = "IDENT" //identifier (x, y)
 	INT   = "INT"   //integer
+	FLOAT = "FLOAT" //floating point number
 	VOID = "VOID"
 
 	ASSIGN   = "="
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
+	INCREMENT       = "++"
+	DECREMENT       = "--"
+
 	PLUS     = "+"
 	MINUS    = "-"
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
+
+	AND = "&&"
+	OR  = "||"
+
+	BIT_AND     = "&"
+	BIT_OR      = "|"
+	BIT_XOR     = "^"
+	BIT_NOT     = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
 
 	COMMA     = ","
 	SEMICOLON = ";"
@@ -32,13 +59,34 @@ const (
 	LET  = "LET"
 	RETURN   = "RETURN"
 	FOR = "FOR"
+	WHILE = "WHILE"
+	BREAK = "BREAK"
+	CONTINUE = "CONTINUE"
 	IN = "IN"
 
 	CLASS = "CLASS"
+	THIS  = "THIS"
+	SUPER = "SUPER"
+	EXTENDS = "EXTENDS"
+	INTERFACE = "INTERFACE"
+	IMPLEMENTS = "IMPLEMENTS"
 
 	STRING = "STRING"
 	IMPORT = "IMPORT"
 
+	// an interpolated string is split into the text before its first ${, the text
+	// between each } and the next ${, and the text after its last }
+	STRING_HEAD   = "STRING_HEAD"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_TAIL   = "STRING_TAIL"
+	// source the lexer couldn't read, like a string left open, whose literal says why
+	INVALID = "INVALID"
+
+	TRY     = "TRY"
+	CATCH   = "CATCH"
+	FINALLY = "FINALLY"
+	THROW   = "THROW"
+
 	TRUE  = "TRUE"
 	FALSE = "FALSE"
 	IF    = "IF"
@@ -58,24 +106,68 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
+	// Doc is the text of the comments directly before the token, if any
+	Doc string
+}
+
+// Position is the location of a token in the source
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position has been set
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String formats the position as file:line:column, leaving out the file if it is unknown
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
 }
 
 var keywords = map[string]TokenType{
 	"func":   FUNCTION,
 	"int":    LET,
+	"float":  LET,
 	"bool":   LET,
 	"string": LET,
 	"array":  LET,
+	"hashmap": LET,
 	"return": RETURN,
 	"true":   TRUE,
 	"false":  FALSE,
 	"if":     IF,
 	"else":   ELSE,
 	"for":    FOR,
+	"while":  WHILE,
+	"break":  BREAK,
+	"continue": CONTINUE,
 	"in":     IN,
 	"void":   VOID,
 	"class":  CLASS,
+	"this":   THIS,
+	"super":  SUPER,
+	"extends": EXTENDS,
+	"interface": INTERFACE,
+	"implements": IMPLEMENTS,
 	"import": IMPORT,
+	"try": TRY,
+	"catch": CATCH,
+	"finally": FINALLY,
+	"throw": THROW,
 }
 
 // LookupIdent checks the keywords table to see if identifier is a keyword
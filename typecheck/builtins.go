@@ -0,0 +1,166 @@
+package typecheck
+
+import (
+	"github.com/OisinA/Azula/ast"
+)
+
+// builtin describes how to check a call to one of the evaluator's builtin functions.
+// A nil entry in params accepts an argument of any type. Where the type of one argument
+// depends on another, like the function given to map, expects gives them instead.
+type builtin struct {
+	minArgs int
+	maxArgs int
+	params  []*Type
+	expects func(args []*Type) []*Type
+	returns func(args []*Type) *Type
+}
+
+func returns(t *Type) func(args []*Type) *Type {
+	return func(args []*Type) *Type {
+		return t
+	}
+}
+
+var builtins = map[string]*builtin{
+	"len":            {minArgs: 1, maxArgs: 1, params: []*Type{nil}, returns: returns(INT)},
+	"input":          {minArgs: 0, maxArgs: 1, params: []*Type{nil}, returns: returns(STRING)},
+	"to_int":         {minArgs: 1, maxArgs: 1, params: []*Type{nil}, returns: returns(INT)},
+	"to_float":       {minArgs: 1, maxArgs: 1, params: []*Type{nil}, returns: returns(FLOAT)},
+	"to_bigint":      {minArgs: 1, maxArgs: 1, params: []*Type{nil}, returns: returns(BIGINT)},
+	"print":          {minArgs: 1, maxArgs: 1, params: []*Type{nil}, returns: returns(VOID)},
+	"range":          {minArgs: 1, maxArgs: 2, params: []*Type{INT, INT}, returns: returns(ArrayOf(INT))},
+	"string_to_list": {minArgs: 1, maxArgs: 1, params: []*Type{STRING}, returns: returns(ArrayOf(STRING))},
+	"append": {minArgs: 2, maxArgs: 2, params: []*Type{ArrayOf(UNKNOWN), nil}, returns: func(args []*Type) *Type {
+		return args[0]
+	}},
+	"type":    {minArgs: 1, maxArgs: 1, params: []*Type{nil}, returns: returns(STRING)},
+	"item_in": {minArgs: 2, maxArgs: 2, params: []*Type{nil, ArrayOf(UNKNOWN)}, returns: returns(BOOL)},
+	"keys": {minArgs: 1, maxArgs: 1, params: []*Type{HashmapOf(UNKNOWN, UNKNOWN)}, returns: func(args []*Type) *Type {
+		if args[0].IsUnknown() {
+			return ArrayOf(UNKNOWN)
+		}
+		return ArrayOf(args[0].Key)
+	}},
+	"values": {minArgs: 1, maxArgs: 1, params: []*Type{HashmapOf(UNKNOWN, UNKNOWN)}, returns: func(args []*Type) *Type {
+		if args[0].IsUnknown() {
+			return ArrayOf(UNKNOWN)
+		}
+		return ArrayOf(args[0].Elem)
+	}},
+	"has_key": {minArgs: 2, maxArgs: 2, params: []*Type{HashmapOf(UNKNOWN, UNKNOWN), nil}, returns: returns(BOOL)},
+	"delete": {minArgs: 2, maxArgs: 2, params: []*Type{HashmapOf(UNKNOWN, UNKNOWN), nil}, returns: func(args []*Type) *Type {
+		return args[0]
+	}},
+	"error": {minArgs: 1, maxArgs: 2, params: []*Type{STRING, STRING}, returns: returns(ERROR)},
+	"map": {minArgs: 2, maxArgs: 2, expects: func(args []*Type) []*Type {
+		return takesElements(1, returnOf(args[1]))(args)
+	}, returns: func(args []*Type) *Type {
+		return ArrayOf(returnOf(args[1]))
+	}},
+	"filter": {minArgs: 2, maxArgs: 2, expects: takesElements(1, BOOL), returns: func(args []*Type) *Type {
+		return args[0]
+	}},
+	"reduce": {minArgs: 3, maxArgs: 3, expects: func(args []*Type) []*Type {
+		return []*Type{ArrayOf(UNKNOWN), FunctionOf([]*Type{args[2], elemOf(args[0])}, args[2]), nil}
+	}, returns: func(args []*Type) *Type {
+		return args[2]
+	}},
+	"sort": {minArgs: 1, maxArgs: 2, expects: takesElements(2, BOOL), returns: func(args []*Type) *Type {
+		return args[0]
+	}},
+	"reverse": {minArgs: 1, maxArgs: 1, expects: stringOrArray(), returns: func(args []*Type) *Type {
+		return args[0]
+	}},
+	"slice": {minArgs: 2, maxArgs: 3, params: []*Type{nil, INT, INT}, returns: func(args []*Type) *Type {
+		return args[0]
+	}},
+	"index_of": {minArgs: 2, maxArgs: 2, expects: stringOrArray(STRING), returns: returns(INT)},
+	"any":      {minArgs: 2, maxArgs: 2, expects: takesElements(1, BOOL), returns: returns(BOOL)},
+	"all":      {minArgs: 2, maxArgs: 2, expects: takesElements(1, BOOL), returns: returns(BOOL)},
+	"zip": {minArgs: 2, maxArgs: 2, expects: func(args []*Type) []*Type {
+		return []*Type{ArrayOf(UNKNOWN), ArrayOf(elemOf(args[0]))}
+	}, returns: func(args []*Type) *Type {
+		return ArrayOf(ArrayOf(elemOf(args[0])))
+	}},
+	"enumerate": {minArgs: 1, maxArgs: 1, params: []*Type{ArrayOf(UNKNOWN)}, returns: func(args []*Type) *Type {
+		return HashmapOf(INT, elemOf(args[0]))
+	}},
+	"split":       {minArgs: 2, maxArgs: 2, params: []*Type{STRING, STRING}, returns: returns(ArrayOf(STRING))},
+	"join":        {minArgs: 2, maxArgs: 2, params: []*Type{ArrayOf(STRING), STRING}, returns: returns(STRING)},
+	"trim":        {minArgs: 1, maxArgs: 1, params: []*Type{STRING}, returns: returns(STRING)},
+	"upper":       {minArgs: 1, maxArgs: 1, params: []*Type{STRING}, returns: returns(STRING)},
+	"lower":       {minArgs: 1, maxArgs: 1, params: []*Type{STRING}, returns: returns(STRING)},
+	"contains":    {minArgs: 2, maxArgs: 2, params: []*Type{STRING, STRING}, returns: returns(BOOL)},
+	"starts_with": {minArgs: 2, maxArgs: 2, params: []*Type{STRING, STRING}, returns: returns(BOOL)},
+	"ends_with":   {minArgs: 2, maxArgs: 2, params: []*Type{STRING, STRING}, returns: returns(BOOL)},
+	"replace":     {minArgs: 3, maxArgs: 3, params: []*Type{STRING, STRING, STRING}, returns: returns(STRING)},
+	"find":        {minArgs: 2, maxArgs: 2, params: []*Type{STRING, STRING}, returns: returns(INT)},
+	"repeat":      {minArgs: 2, maxArgs: 2, params: []*Type{STRING, INT}, returns: returns(STRING)},
+	"substring":   {minArgs: 2, maxArgs: 3, params: []*Type{STRING, INT, INT}, returns: returns(STRING)},
+	"pad_left":    {minArgs: 2, maxArgs: 3, params: []*Type{STRING, INT, STRING}, returns: returns(STRING)},
+	"pad_right":   {minArgs: 2, maxArgs: 3, params: []*Type{STRING, INT, STRING}, returns: returns(STRING)},
+	"chr":         {minArgs: 1, maxArgs: 1, params: []*Type{INT}, returns: returns(STRING)},
+	"ord":         {minArgs: 1, maxArgs: 1, params: []*Type{STRING}, returns: returns(INT)},
+}
+
+// elemOf gives the element type of an array, which is unknown if the array's type is
+func elemOf(t *Type) *Type {
+	if t.IsUnknown() || t.Elem == nil {
+		return UNKNOWN
+	}
+	return t.Elem
+}
+
+// returnOf gives the return type of a function, which is unknown if the function's type is
+func returnOf(t *Type) *Type {
+	if t.IsUnknown() || t.Return == nil {
+		return UNKNOWN
+	}
+	return t.Return
+}
+
+// takesElements gives the types expected by a builtin like filter, whose function is
+// given the elements of the array and returns ret
+func takesElements(n int, ret *Type) func(args []*Type) []*Type {
+	return func(args []*Type) []*Type {
+		params := []*Type{}
+		for i := 0; i < n; i++ {
+			params = append(params, elemOf(args[0]))
+		}
+		return []*Type{ArrayOf(UNKNOWN), FunctionOf(params, ret)}
+	}
+}
+
+// stringOrArray gives the types expected by a builtin like reverse, which takes a string
+// or an array. A string is given the rest of its arguments as strings, and an array
+// anything.
+func stringOrArray(rest ...*Type) func(args []*Type) []*Type {
+	return func(args []*Type) []*Type {
+		if args[0] == STRING {
+			return append([]*Type{STRING}, rest...)
+		}
+		return append([]*Type{ArrayOf(UNKNOWN)}, make([]*Type, len(rest))...)
+	}
+}
+
+func (b *builtin) check(c *Checker, node *ast.CallExpression, args []*Type) *Type {
+	name := node.Function.TokenLiteral()
+	if len(args) < b.minArgs || len(args) > b.maxArgs {
+		if b.minArgs == b.maxArgs {
+			c.addError(node.Pos(), "wrong number of arguments to %s. got=%d, want=%d", name, len(args), b.minArgs)
+		} else {
+			c.addError(node.Pos(), "wrong number of arguments to %s. got=%d, want %d to %d", name, len(args), b.minArgs, b.maxArgs)
+		}
+		return UNKNOWN
+	}
+	params := b.params
+	if b.expects != nil {
+		params = b.expects(args)
+	}
+	for i, arg := range args {
+		if params[i] != nil && !Assignable(params[i], arg) {
+			c.addError(node.Arguments[i].Pos(), "argument %d to %s must be %s, not %s", i+1, name, params[i], arg)
+		}
+	}
+	return b.returns(args)
+}
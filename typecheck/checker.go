@@ -0,0 +1,1130 @@
+package typecheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/module"
+	"github.com/OisinA/Azula/token"
+)
+
+// Error is a type error along with the position in the source it occurred at
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
+type scope struct {
+	store map[string]*Type
+	// pending holds the functions, classes and interfaces declared in the scope that
+	// haven't been defined yet, as the code before their definition runs without them
+	pending map[string]bool
+	// function is set for the body of a function or class, which runs when it's called
+	function bool
+	outer    *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{store: make(map[string]*Type), pending: make(map[string]bool), outer: outer}
+}
+
+func (s *scope) get(name string) (*Type, bool) {
+	t, ok := s.store[name]
+	if !ok && s.outer != nil {
+		t, ok = s.outer.get(name)
+	}
+	return t, ok
+}
+
+func (s *scope) set(name string, t *Type) {
+	s.store[name] = t
+}
+
+// beforeDefinition reports whether name is used before the definition it refers to has
+// run. Code in a function defined in the meantime is fine, as it only runs once called.
+func (s *scope) beforeDefinition(name string) bool {
+	for ; s != nil; s = s.outer {
+		if s.pending[name] {
+			return true
+		}
+		if _, ok := s.store[name]; ok || s.function {
+			return false
+		}
+	}
+	return false
+}
+
+// class holds the fields and methods of a class. An interface is a class with only
+// the methods it declares.
+type class struct {
+	Type       *Type
+	Parent     *class
+	Interfaces []*class
+	Fields     map[string]*Type
+	Methods    map[string]*Type
+	Interface  bool
+}
+
+// kind gives what the class is called in errors
+func (cl *class) kind() string {
+	if cl.Interface {
+		return "interface"
+	}
+	return "class"
+}
+
+// field looks up a field of the class or the classes it inherits from
+func (cl *class) field(name string) (*Type, bool) {
+	for ; cl != nil; cl = cl.Parent {
+		if t, ok := cl.Fields[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// method looks up a method of the class or the classes it inherits from
+func (cl *class) method(name string) (*Type, bool) {
+	for ; cl != nil; cl = cl.Parent {
+		if t, ok := cl.Methods[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func newClass(name string) *class {
+	return &class{Type: &Type{Name: name}, Fields: make(map[string]*Type), Methods: make(map[string]*Type)}
+}
+
+// Checker walks a program and reports every type error it finds, without running it
+type Checker struct {
+	errors  []*Error
+	scope   *scope
+	classes map[string]*class
+	returns []*Type
+	this    *Type
+	loops   int
+	// modules holds the type of each module checked so far, by file
+	modules map[string]*Type
+	loading module.Stack
+}
+
+// New gives a Checker with an empty global scope
+func New() *Checker {
+	return &Checker{
+		errors:  []*Error{},
+		scope:   newScope(nil),
+		classes: make(map[string]*class),
+		modules: make(map[string]*Type),
+	}
+}
+
+// Check type checks the program and gives all the errors found
+func Check(program *ast.Program) []*Error {
+	c := New()
+	c.Check(program)
+	return c.Errors()
+}
+
+// Check type checks the program in the checker's global scope
+func (c *Checker) Check(program *ast.Program) {
+	c.checkStatements(program.Statements)
+}
+
+// Define declares a name in the checker's global scope, for one the program is given
+// rather than declaring itself. A name of unknown type can be used as anything.
+func (c *Checker) Define(name string, t *Type) {
+	c.scope.set(name, t)
+}
+
+// Extend type checks a program that carries on from those checked before it, as in a
+// repl, giving the errors found in it. A program with errors won't be run, so the
+// checker keeps nothing it declares, nor its errors.
+func (c *Checker) Extend(program *ast.Program) []*Error {
+	global, restore := c.scope, c.snapshot()
+	entry := newScope(global)
+	c.scope = entry
+	c.Check(program)
+	classes := c.classes
+	if errors := restore(); len(errors) != 0 {
+		return errors
+	}
+	c.classes = classes
+	for name, t := range entry.store {
+		global.set(name, t)
+	}
+	return nil
+}
+
+// TypeOf gives the type of a program's last statement, or void if it isn't an
+// expression, along with the errors found in the program. It is checked in a scope of
+// its own, so nothing it defines is kept, and its errors aren't added to the checker's.
+func (c *Checker) TypeOf(program *ast.Program) (*Type, []*Error) {
+	restore := c.snapshot()
+	c.scope = newScope(c.scope)
+	c.declare(program.Statements)
+	t := VOID
+	for i, stmt := range program.Statements {
+		if es, ok := stmt.(*ast.ExpressionStatement); ok && i == len(program.Statements)-1 {
+			t = c.checkExpression(es.Expression)
+			continue
+		}
+		c.checkStatement(stmt)
+	}
+	return t, restore()
+}
+
+// snapshot saves the checker's scope, classes and errors, giving a function that goes
+// back to them and gives the errors found in the meantime
+func (c *Checker) snapshot() func() []*Error {
+	seen, scope := len(c.errors), c.scope
+	classes := make(map[string]*class, len(c.classes))
+	for name, cl := range c.classes {
+		classes[name] = cl
+	}
+	return func() []*Error {
+		errors := make([]*Error, len(c.errors)-seen)
+		copy(errors, c.errors[seen:])
+		c.errors, c.scope, c.classes = c.errors[:seen], scope, classes
+		return errors
+	}
+}
+
+// Errors gives the errors found so far
+func (c *Checker) Errors() []*Error {
+	return c.errors
+}
+
+func (c *Checker) addError(pos token.Position, format string, a ...interface{}) {
+	c.errors = append(c.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
+// resolveType gives the type named by a declaration. Arrays are declared as
+// array(T), which the parser stores as a kind of "array" and a name of T, and
+// hashmaps as hashmap(K, V), stored as a kind of "hashmap" and a name of "K, V".
+// T, K and V can be any type, written out in full.
+// Function types are stored as a kind of "func" and the whole type as the name.
+func (c *Checker) resolveType(pos token.Position, kind string, name string) *Type {
+	switch kind {
+	case "func":
+		return c.resolveFunctionType(pos, name)
+	case "array":
+		return ArrayOf(c.resolveTypeName(pos, name))
+	case "hashmap":
+		parts := ast.SplitTypes(name)
+		if len(parts) != 2 {
+			c.addError(pos, "hashmap needs a key and value type")
+			return UNKNOWN
+		}
+		return HashmapOf(c.resolveTypeName(pos, parts[0]), c.resolveTypeName(pos, parts[1]))
+	}
+	switch name {
+	case "int":
+		return INT
+	case "float":
+		return FLOAT
+	case "bigint":
+		return BIGINT
+	case "bool":
+		return BOOL
+	case "string":
+		return STRING
+	case "void":
+		return VOID
+	case "error":
+		return ERROR
+	}
+	if cl, ok := c.classes[name]; ok {
+		return cl.Type
+	}
+	c.addError(pos, "unknown type %s", name)
+	return UNKNOWN
+}
+
+// resolveFunctionType gives the type named by a function type, as in func(int): bool
+func (c *Checker) resolveFunctionType(pos token.Position, name string) *Type {
+	paramNames, ret, ok := ast.SplitFunctionType(name)
+	if !ok {
+		c.addError(pos, "invalid function type %s", name)
+		return UNKNOWN
+	}
+	params := []*Type{}
+	for _, p := range paramNames {
+		params = append(params, c.resolveTypeName(pos, p))
+	}
+	return FunctionOf(params, c.resolveTypeName(pos, ret))
+}
+
+// resolveTypeName gives the type written as name, splitting it into the kind and name
+// that resolveType takes
+func (c *Checker) resolveTypeName(pos token.Position, name string) *Type {
+	if strings.HasPrefix(name, "func(") {
+		return c.resolveType(pos, "func", name)
+	}
+	open := strings.Index(name, "(")
+	if open < 0 {
+		return c.resolveType(pos, name, name)
+	}
+	return c.resolveType(pos, name[:open], name[open+1:len(name)-1])
+}
+
+func (c *Checker) signature(fn *ast.FunctionLiteral) *Type {
+	params := []*Type{}
+	for _, p := range fn.Parameters {
+		params = append(params, c.resolveType(p.ReturnType.Token.Pos, p.ReturnType.Token.Literal, p.ReturnType.Value))
+	}
+	ret := c.resolveType(fn.ReturnType.Token.Pos, fn.ReturnType.Token.Literal, fn.ReturnType.Value)
+	return FunctionOf(params, ret)
+}
+
+// declare hoists the classes and functions defined in a block, so they can be
+// referred to before their definition
+func (c *Checker) declare(stmts []ast.Statement) {
+	classes := []*ast.ClassLiteral{}
+	interfaces := []*ast.InterfaceLiteral{}
+	for _, s := range stmts {
+		if es, ok := s.(*ast.ExpressionStatement); ok {
+			switch exp := es.Expression.(type) {
+			case *ast.ClassLiteral:
+				if exp.Name != nil {
+					c.classes[exp.Name.Value] = newClass(exp.Name.Value)
+					c.scope.pending[exp.Name.Value] = true
+					classes = append(classes, exp)
+				}
+			case *ast.InterfaceLiteral:
+				c.scope.pending[exp.Name.Value] = true
+				iface := newClass(exp.Name.Value)
+				iface.Interface = true
+				c.classes[exp.Name.Value] = iface
+				interfaces = append(interfaces, exp)
+			}
+		}
+	}
+
+	for _, iface := range interfaces {
+		c.declareInterface(iface)
+	}
+	for _, cl := range classes {
+		c.declareClass(cl)
+	}
+
+	for _, s := range stmts {
+		if es, ok := s.(*ast.ExpressionStatement); ok {
+			if fn, ok := es.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil {
+				c.scope.set(fn.Name.Value, c.signature(fn))
+				c.scope.pending[fn.Name.Value] = true
+			}
+		}
+	}
+}
+
+func (c *Checker) declareClass(cl *ast.ClassLiteral) {
+	info := c.classes[cl.Name.Value]
+	params := []*Type{}
+	for _, p := range cl.Parameters {
+		param := c.resolveType(p.ReturnType.Token.Pos, p.ReturnType.Token.Literal, p.ReturnType.Value)
+		params = append(params, param)
+		info.Fields[p.Value] = param
+	}
+	c.scope.set(cl.Name.Value, FunctionOf(params, info.Type))
+
+	if cl.Parent != nil {
+		parent, ok := c.classes[cl.Parent.Value]
+		switch {
+		case !ok:
+			c.addError(cl.Parent.Pos(), "%s is not a class", cl.Parent.Value)
+		case parent.inherits(info):
+			c.addError(cl.Parent.Pos(), "class %s can't extend %s, which inherits from it", cl.Name.Value, cl.Parent.Value)
+		default:
+			info.Parent = parent
+			info.Type.Parent = parent.Type
+		}
+	}
+
+	for _, name := range cl.Interfaces {
+		iface, ok := c.classes[name.Value]
+		if !ok || !iface.Interface {
+			c.addError(name.Pos(), "%s is not an interface", name.Value)
+			continue
+		}
+		info.Interfaces = append(info.Interfaces, iface)
+		info.Type.Interfaces = append(info.Type.Interfaces, iface.Type)
+	}
+
+	if cl.Body == nil {
+		return
+	}
+	for _, s := range cl.Body.Statements {
+		switch s := s.(type) {
+		case *ast.LetStatement:
+			info.Fields[s.Name.Value] = c.resolveType(s.Token.Pos, s.Token.Literal, s.Name.ReturnType.Value)
+		case *ast.ExpressionStatement:
+			if fn, ok := s.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil {
+				info.Methods[fn.Name.Value] = c.signature(fn)
+			}
+		}
+	}
+}
+
+func (c *Checker) declareInterface(node *ast.InterfaceLiteral) {
+	info := c.classes[node.Name.Value]
+	for _, m := range node.Methods {
+		params := []*Type{}
+		for _, p := range m.Parameters {
+			params = append(params, c.resolveType(p.ReturnType.Token.Pos, p.ReturnType.Token.Literal, p.ReturnType.Value))
+		}
+		ret := c.resolveType(m.ReturnType.Token.Pos, m.ReturnType.Token.Literal, m.ReturnType.Value)
+		info.Methods[m.Name.Value] = FunctionOf(params, ret)
+	}
+}
+
+// checkImplements checks a class has every method of the interfaces it implements
+func (c *Checker) checkImplements(node *ast.ClassLiteral, info *class) {
+	for i, iface := range info.Interfaces {
+		names := []string{}
+		for name := range iface.Methods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			want := iface.Methods[name]
+			got, ok := info.method(name)
+			if !ok {
+				c.addError(node.Interfaces[i].Pos(), "class %s doesn't implement %s: missing method %s", info.Type, iface.Type, name)
+				continue
+			}
+			if got.String() != want.String() {
+				c.addError(node.Interfaces[i].Pos(), "class %s doesn't implement %s: method %s is %s, not %s", info.Type, iface.Type, name, got, want)
+			}
+		}
+	}
+}
+
+// inherits reports whether the class is other or one of its subclasses
+func (cl *class) inherits(other *class) bool {
+	for ; cl != nil; cl = cl.Parent {
+		if cl == other {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) checkStatements(stmts []ast.Statement) {
+	c.declare(stmts)
+	for _, s := range stmts {
+		c.checkStatement(s)
+	}
+}
+
+func (c *Checker) checkStatement(node ast.Statement) {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		c.checkExpression(node.Expression)
+
+	case *ast.BlockStatement:
+		c.checkStatements(node.Statements)
+
+	case *ast.LetStatement:
+		declared := c.resolveType(node.Token.Pos, node.Token.Literal, node.Name.ReturnType.Value)
+		val := c.checkExpression(node.Value)
+		if !Assignable(declared, val) {
+			c.addError(node.Pos(), "trying to assign %s to %s %s", val, declared, node.Name.Value)
+		}
+		c.scope.set(node.Name.Value, declared)
+
+	case *ast.ReassignStatement:
+		declared, ok := c.scope.get(node.Name.Value)
+		if !ok {
+			c.checkExpression(node.Value)
+			c.addError(node.Pos(), "can't reassign value to non-existent variable '%s'", node.Name.Value)
+			return
+		}
+		val := c.checkAssigned(node.Pos(), node.Operator, declared, node.Value)
+		if !Assignable(declared, val) {
+			c.addError(node.Pos(), "can't assign value of type %s to variable of type %s", val, declared)
+		}
+
+	case *ast.ReturnStatement:
+		val := c.checkExpression(node.ReturnValue)
+		if len(c.returns) == 0 {
+			return
+		}
+		expected := c.returns[len(c.returns)-1]
+		if expected == VOID && val != VOID && !val.IsUnknown() || expected != VOID && !Assignable(expected, val) {
+			c.addError(node.Pos(), "returning %s from function that returns %s", val, expected)
+		}
+
+	case *ast.PropertyAssignment:
+		obj, field := c.checkProperty(node.Property)
+		val := c.checkAssigned(node.Pos(), node.Operator, field, node.Value)
+		if obj.Name == "module" {
+			c.addError(node.Pos(), "can't assign to %s of module %s", node.Property.Property.Value, obj.Module)
+			return
+		}
+		if obj == ERROR {
+			c.addError(node.Pos(), "can't assign to %s of an error", node.Property.Property.Value)
+			return
+		}
+		if !Assignable(field, val) {
+			c.addError(node.Pos(), "can't assign value of type %s to field %s of type %s", val, node.Property.Property.Value, field)
+		}
+
+	case *ast.IndexAssignment:
+		left := c.checkExpression(node.Index.Left)
+		elem := c.checkIndex(node.Index, left)
+		if left == STRING {
+			c.addError(node.Pos(), "can't assign to a character of a string")
+			return
+		}
+		val := c.checkAssigned(node.Pos(), node.Operator, elem, node.Value)
+		if !Assignable(elem, val) {
+			c.addError(node.Pos(), "can't assign value of type %s to element of type %s", val, elem)
+		}
+
+	case *ast.ImportStatement:
+		c.checkImport(node)
+
+	case *ast.BreakStatement:
+		if c.loops == 0 {
+			c.addError(node.Pos(), "break outside of a loop")
+		}
+
+	case *ast.ContinueStatement:
+		if c.loops == 0 {
+			c.addError(node.Pos(), "continue outside of a loop")
+		}
+
+	case *ast.TryStatement:
+		c.checkStatement(node.Body)
+		for _, clause := range node.Catches {
+			c.scope = newScope(c.scope)
+			c.scope.set(clause.Name.Value, ERROR)
+			c.checkStatement(clause.Body)
+			c.scope = c.scope.outer
+		}
+		if node.Finally != nil {
+			c.checkStatement(node.Finally)
+		}
+
+	case *ast.ThrowStatement:
+		val := c.checkExpression(node.Value)
+		if !Assignable(STRING, val) && !Assignable(ERROR, val) {
+			c.addError(node.Pos(), "can only throw a string or an error, not %s", val)
+		}
+	}
+}
+
+// checkImport checks the module an import refers to the first time it is imported,
+// and binds the names it exports
+func (c *Checker) checkImport(node *ast.ImportStatement) {
+	str, ok := node.Value.(*ast.StringLiteral)
+	if !ok {
+		c.addError(node.Pos(), "invalid import path")
+		return
+	}
+	file, ok := module.Resolve(str.Value, node.Pos().Filename)
+	if !ok {
+		c.addError(node.Pos(), "couldn't import file '%s'", str.Value)
+		return
+	}
+	name := module.Name(file)
+	if node.Alias != nil {
+		name = node.Alias.Value
+	} else if !module.IsValidName(name) {
+		c.addError(node.Pos(), "can't use %s as the name of a module, import it with as", name)
+		return
+	}
+
+	mod, ok := c.modules[file]
+	if !ok {
+		if cycle, ok := c.loading.Cycle(file, node.Pos().Filename); ok {
+			c.addError(node.Pos(), "import cycle: %s", cycle)
+			return
+		}
+		program, err := module.Parse(file)
+		if err != nil {
+			c.addError(node.Pos(), "something went wrong while importing '%s': %s", str.Value, err)
+			return
+		}
+		loading := c.loading
+		c.loading = loading.Enter(file, node.Pos().Filename)
+		mod = c.checkModule(file, program)
+		c.loading = loading
+		c.modules[file] = mod
+	}
+	c.scope.set(name, mod)
+}
+
+// checkModule checks the top level of a module in a scope of its own, giving the
+// type of the module. Its classes can be used as types by the importing file.
+func (c *Checker) checkModule(file string, program *ast.Program) *Type {
+	scope, returns, this, loops := c.scope, c.returns, c.this, c.loops
+	c.scope, c.returns, c.this, c.loops = newScope(nil), nil, nil, 0
+	c.Check(program)
+
+	mod := &Type{Name: "module", Module: module.Name(file), Members: make(map[string]*Type)}
+	for name, t := range c.scope.store {
+		if module.Exported(name) {
+			mod.Members[name] = t
+		}
+	}
+	c.scope, c.returns, c.this, c.loops = scope, returns, this, loops
+	return mod
+}
+
+func (c *Checker) checkExpression(node ast.Expression) *Type {
+	switch node := node.(type) {
+	case *ast.IntegerLiteral:
+		return INT
+
+	case *ast.FloatLiteral:
+		return FLOAT
+
+	case *ast.Boolean:
+		return BOOL
+
+	case *ast.StringLiteral:
+		return STRING
+
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			if t := c.checkExpression(part); t == VOID {
+				c.addError(part.Pos(), "can't put void in a string")
+			}
+		}
+		return STRING
+
+	case *ast.ArrayLiteral:
+		elem := UNKNOWN
+		for _, e := range node.Elements {
+			t := c.checkExpression(e)
+			if elem.IsUnknown() {
+				elem = t
+				continue
+			}
+			if !Assignable(elem, t) {
+				c.addError(e.Pos(), "trying to assign %s to array of %s", t, elem)
+			}
+		}
+		return ArrayOf(elem)
+
+	case *ast.HashLiteral:
+		key, value := UNKNOWN, UNKNOWN
+		for i, k := range node.Keys {
+			kt := c.checkExpression(k)
+			vt := c.checkExpression(node.Values[i])
+			if !kt.IsUnknown() && !hashable(kt) {
+				c.addError(k.Pos(), "unusable as hashmap key: %s", kt)
+			}
+			if key.IsUnknown() {
+				key = kt
+			} else if !Assignable(key, kt) {
+				c.addError(k.Pos(), "trying to use %s as key of hashmap(%s, %s)", kt, key, value)
+			}
+			if value.IsUnknown() {
+				value = vt
+			} else if !Assignable(value, vt) {
+				c.addError(node.Values[i].Pos(), "trying to use %s as value of hashmap(%s, %s)", vt, key, value)
+			}
+		}
+		return HashmapOf(key, value)
+
+	case *ast.Identifier:
+		return c.checkIdentifier(node.Pos(), node.Value)
+
+	case *ast.TypedIdentifier:
+		return c.checkIdentifier(node.Pos(), node.Value)
+
+	case *ast.PrefixExpression:
+		right := c.checkExpression(node.Right)
+		switch node.Operator {
+		case "!":
+			return BOOL
+		case "-":
+			if right.IsUnknown() || isNumber(right) {
+				return right
+			}
+			c.addError(node.Pos(), "unknown operator: -%s", right)
+			return UNKNOWN
+		case "~":
+			if right.IsUnknown() {
+				return INT
+			}
+			if right == INT || right == BIGINT {
+				return right
+			}
+			c.addError(node.Pos(), "unknown operator: ~%s", right)
+			return UNKNOWN
+		default:
+			c.addError(node.Pos(), "unknown operator: %s%s", node.Operator, right)
+			return UNKNOWN
+		}
+
+	case *ast.InfixExpression:
+		left := c.checkExpression(node.Left)
+		right := c.checkExpression(node.Right)
+		return c.checkOperator(node.Pos(), node.Operator, left, right)
+
+	case *ast.IfExpression:
+		c.checkExpression(node.Condition)
+		c.checkStatements(node.Consequence.Statements)
+		if node.Alternative != nil {
+			c.checkStatements(node.Alternative.Statements)
+		}
+		return UNKNOWN
+
+	case *ast.IndexExpression:
+		return c.checkIndex(node, c.checkExpression(node.Left))
+
+	case *ast.SliceExpression:
+		left := c.checkExpression(node.Left)
+		for _, bound := range []ast.Expression{node.Start, node.End} {
+			if bound == nil {
+				continue
+			}
+			if t := c.checkExpression(bound); !Assignable(INT, t) {
+				c.addError(bound.Pos(), "slice bounds must be int, not %s", t)
+			}
+		}
+		if left.IsUnknown() {
+			return UNKNOWN
+		}
+		if left.Name != "array" && left != STRING {
+			c.addError(node.Pos(), "slice operator not supported: %s", left)
+			return UNKNOWN
+		}
+		return left
+
+	case *ast.ForLiteral:
+		iter := c.checkExpression(node.Iterator)
+		elem := UNKNOWN
+		if !iter.IsUnknown() {
+			if iter.Name != "array" {
+				c.addError(node.Iterator.Pos(), "iterator must be an array, not %s", iter)
+			} else {
+				elem = iter.Elem
+			}
+		}
+		c.scope = newScope(c.scope)
+		c.scope.set(node.Parameter.Value, elem)
+		c.loops++
+		c.checkStatements(node.Body.Statements)
+		c.loops--
+		c.scope = c.scope.outer
+		return UNKNOWN
+
+	case *ast.WhileLiteral:
+		c.scope = newScope(c.scope)
+		cond := c.checkExpression(node.Condition)
+		if !Assignable(BOOL, cond) {
+			c.addError(node.Condition.Pos(), "while condition must be bool, not %s", cond)
+		}
+		c.loops++
+		c.checkStatements(node.Body.Statements)
+		c.loops--
+		c.scope = c.scope.outer
+		return UNKNOWN
+
+	case *ast.FunctionLiteral:
+		return c.checkFunction(node)
+
+	case *ast.ClassLiteral:
+		return c.checkClass(node)
+
+	case *ast.InterfaceLiteral:
+		delete(c.scope.pending, node.Name.Value)
+		return UNKNOWN
+
+	case *ast.CallExpression:
+		return c.checkCall(node)
+
+	case *ast.PropertyExpression:
+		_, t := c.checkProperty(node)
+		return t
+
+	case *ast.ThisExpression:
+		if c.this == nil {
+			c.addError(node.Pos(), "this outside of a class")
+			return UNKNOWN
+		}
+		return c.this
+
+	case *ast.SuperExpression:
+		if c.this == nil || c.this.Parent == nil {
+			c.addError(node.Pos(), "super outside of a subclass")
+			return UNKNOWN
+		}
+		return c.this.Parent
+	}
+	return UNKNOWN
+}
+
+// checkProperty gives the type of the object whose property is used, and the type of the property
+func (c *Checker) checkProperty(node *ast.PropertyExpression) (*Type, *Type) {
+	obj := c.checkExpression(node.Object)
+	name := node.Property.Value
+	if obj.Name == "module" {
+		return obj, c.member(obj, node.Property.Pos(), name)
+	}
+	if obj == ERROR {
+		if t, ok := errorFields[name]; ok {
+			return obj, t
+		}
+		c.addError(node.Property.Pos(), "error has no field %s", name)
+		return obj, UNKNOWN
+	}
+	cl := c.classOf(obj, node.Object, "field "+name)
+	if cl == nil {
+		return obj, UNKNOWN
+	}
+	if t, ok := cl.field(name); ok {
+		return obj, t
+	}
+	if t, ok := cl.method(name); ok {
+		return obj, t
+	}
+	c.addError(node.Property.Pos(), "%s %s has no field %s", cl.kind(), cl.Type, name)
+	return obj, UNKNOWN
+}
+
+// member gives the type of a name exported by a module
+func (c *Checker) member(mod *Type, pos token.Position, name string) *Type {
+	if t, ok := mod.Members[name]; ok {
+		return t
+	}
+	c.addError(pos, "module %s doesn't export %s", mod.Module, name)
+	return UNKNOWN
+}
+
+// classOf gives the class of an object of type t whose property is being used, or nil
+// if it isn't known or isn't an object
+func (c *Checker) classOf(t *Type, node ast.Expression, property string) *class {
+	if t.IsUnknown() {
+		return nil
+	}
+	cl, ok := c.classes[t.Name]
+	if !ok {
+		c.addError(node.Pos(), "can't get %s of %s, it is not an object", property, t)
+		return nil
+	}
+	return cl
+}
+
+func hashable(t *Type) bool {
+	return t == INT || t == FLOAT || t == BIGINT || t == BOOL || t == STRING
+}
+
+func isNumber(t *Type) bool {
+	return t == INT || t == FLOAT || t == BIGINT
+}
+
+func (c *Checker) checkIdentifier(pos token.Position, name string) *Type {
+	if c.scope.beforeDefinition(name) {
+		c.addError(pos, "%s is used before its definition", name)
+	}
+	if t, ok := c.scope.get(name); ok {
+		return t
+	}
+	if _, ok := builtins[name]; ok {
+		return UNKNOWN
+	}
+	c.addError(pos, "identifier not found: %s", name)
+	return UNKNOWN
+}
+
+// checkAssigned gives the type of the value an assignment sets, which for a compound
+// assignment is that of its operator applied to the old and given values
+func (c *Checker) checkAssigned(pos token.Position, operator string, old *Type, value ast.Expression) *Type {
+	val := c.checkExpression(value)
+	if operator == "" {
+		return val
+	}
+	return c.checkOperator(pos, operator, old, val)
+}
+
+// checkOperator gives the type of applying an infix operator to values of the given types
+func (c *Checker) checkOperator(pos token.Position, operator string, left *Type, right *Type) *Type {
+	switch operator {
+	case "==", "!=":
+		if isNumber(left) && isNumber(right) && left != right {
+			c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+		}
+		return BOOL
+	case "&&", "||":
+		if !(left.IsUnknown() || left == BOOL) || !(right.IsUnknown() || right == BOOL) {
+			c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+		}
+		return BOOL
+	case "<", ">", "<=", ">=":
+		if c.numeric(left, right) == nil {
+			c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+		}
+		return BOOL
+	case "+":
+		if left.IsUnknown() || right.IsUnknown() {
+			return UNKNOWN
+		}
+		if t := c.numeric(left, right); t != nil {
+			return t
+		}
+		if isNumber(left) && isNumber(right) {
+			c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+			return UNKNOWN
+		}
+		// anything else added together is joined as strings
+		return STRING
+	case "-", "*", "/":
+		t := c.numeric(left, right)
+		if t == nil {
+			c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+			return UNKNOWN
+		}
+		return t
+	case "%", "&", "|", "^", "<<", ">>":
+		// these are only for ints and bigints
+		t := c.numeric(left, right)
+		if t == nil {
+			c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+			return UNKNOWN
+		}
+		if t == FLOAT {
+			c.addError(pos, "unknown operator: %s %s %s", left, operator, right)
+			return UNKNOWN
+		}
+		if t == BIGINT {
+			return BIGINT
+		}
+		return INT
+	default:
+		c.addError(pos, "unknown operator: %s %s %s", left, operator, right)
+		return UNKNOWN
+	}
+}
+
+// numeric gives the type of arithmetic between left and right, or nil if they
+// aren't numbers of the same type. There is no implicit conversion between ints,
+// floats and bigints.
+func (c *Checker) numeric(left *Type, right *Type) *Type {
+	switch {
+	case left.IsUnknown() && right.IsUnknown():
+		return UNKNOWN
+	case left.IsUnknown() && isNumber(right):
+		return right
+	case right.IsUnknown() && isNumber(left):
+		return left
+	case isNumber(left) && left == right:
+		return left
+	}
+	return nil
+}
+
+func (c *Checker) checkFunction(node *ast.FunctionLiteral) *Type {
+	var sig *Type
+	if node.Name == nil {
+		sig = c.signature(node)
+	} else if t, ok := c.scope.store[node.Name.Value]; ok && t.Name == "func" {
+		sig = t
+	} else {
+		sig = c.signature(node)
+		c.scope.set(node.Name.Value, sig)
+	}
+	if node.Name != nil {
+		// the function can call itself, as its body runs after it's defined
+		delete(c.scope.pending, node.Name.Value)
+	}
+
+	c.scope = newScope(c.scope)
+	c.scope.function = true
+	for i, p := range node.Parameters {
+		c.scope.set(p.Value, sig.Params[i])
+	}
+	c.returns = append(c.returns, sig.Return)
+	// a break in a function can't reach a loop the function is defined in
+	loops := c.loops
+	c.loops = 0
+	c.checkStatements(node.Body.Statements)
+	c.loops = loops
+	c.returns = c.returns[:len(c.returns)-1]
+	c.scope = c.scope.outer
+
+	return sig
+}
+
+func (c *Checker) checkClass(node *ast.ClassLiteral) *Type {
+	if _, ok := c.classes[node.Name.Value]; !ok {
+		c.classes[node.Name.Value] = newClass(node.Name.Value)
+		c.declareClass(node)
+	}
+	ctor, _ := c.scope.get(node.Name.Value)
+	info := c.classes[node.Name.Value]
+	c.checkImplements(node, info)
+	// the class it extends and the interfaces it implements are looked up as it's
+	// defined. A parent that couldn't be extended has been reported already.
+	names := node.Interfaces
+	if info.Parent != nil {
+		names = append([]*ast.Identifier{node.Parent}, names...)
+	}
+	for _, name := range names {
+		if c.scope.beforeDefinition(name.Value) {
+			c.addError(name.Pos(), "%s is used before its definition", name.Value)
+		}
+	}
+	delete(c.scope.pending, node.Name.Value)
+
+	c.scope = newScope(c.scope)
+	c.scope.function = true
+	c.inherit(info.Parent)
+	for i, p := range node.Parameters {
+		c.scope.set(p.Value, ctor.Params[i])
+	}
+	if info.Parent != nil {
+		c.checkSuperConstructor(node, ctor, info.Parent)
+	}
+	loops, this := c.loops, c.this
+	c.loops, c.this = 0, info.Type
+	c.checkStatements(node.Body.Statements)
+	c.loops, c.this = loops, this
+	c.scope = c.scope.outer
+
+	return ctor
+}
+
+// inherit brings the fields and methods of a parent class into scope, so a subclass
+// can use them by name
+func (c *Checker) inherit(parent *class) {
+	if parent == nil {
+		return
+	}
+	c.inherit(parent.Parent)
+	for name, t := range parent.Fields {
+		c.scope.set(name, t)
+	}
+	for name, t := range parent.Methods {
+		c.scope.set(name, t)
+	}
+}
+
+// checkSuperConstructor checks the arguments passed to the parent's constructor, which
+// are the class's own arguments when none are given
+func (c *Checker) checkSuperConstructor(node *ast.ClassLiteral, ctor *Type, parent *class) {
+	parentCtor, ok := c.scope.get(parent.Type.Name)
+	if !ok || parentCtor.Name != "func" {
+		return
+	}
+	args := ctor.Params
+	if node.ParentArguments != nil {
+		args = []*Type{}
+		for _, a := range node.ParentArguments {
+			args = append(args, c.checkExpression(a))
+		}
+	}
+	name := parent.Type.Name
+	if len(args) != len(parentCtor.Params) {
+		c.addError(node.Parent.Pos(), "wrong number of arguments to %s. got=%d, want=%d", name, len(args), len(parentCtor.Params))
+		return
+	}
+	for i, arg := range args {
+		if !Assignable(parentCtor.Params[i], arg) {
+			c.addError(node.Parent.Pos(), "argument %d to %s must be %s, not %s", i+1, name, parentCtor.Params[i], arg)
+		}
+	}
+}
+
+func (c *Checker) checkCall(node *ast.CallExpression) *Type {
+	args := []*Type{}
+	for _, a := range node.Arguments {
+		args = append(args, c.checkExpression(a))
+	}
+
+	name := node.Function.TokenLiteral()
+
+	if node.Outer != nil {
+		outer := c.checkExpression(node.Outer)
+		if outer.Name == "module" {
+			fn := c.member(outer, node.Function.Pos(), name)
+			if fn.IsUnknown() {
+				return UNKNOWN
+			}
+			if fn.Name != "func" {
+				c.addError(node.Pos(), "not a function: %s", fn)
+				return UNKNOWN
+			}
+			return c.checkArguments(node, name, fn, args)
+		}
+		cl := c.classOf(outer, node.Outer, "method "+name)
+		if cl == nil {
+			return UNKNOWN
+		}
+		method, ok := cl.method(name)
+		if !ok {
+			c.addError(node.Function.Pos(), "%s %s has no method %s", cl.kind(), cl.Type, name)
+			return UNKNOWN
+		}
+		return c.checkArguments(node, name, method, args)
+	}
+
+	if ident, ok := node.Function.(*ast.Identifier); ok {
+		if _, declared := c.scope.get(ident.Value); !declared {
+			if b, ok := builtins[ident.Value]; ok {
+				return b.check(c, node, args)
+			}
+		}
+	}
+
+	fn := c.checkExpression(node.Function)
+	if fn.IsUnknown() {
+		return UNKNOWN
+	}
+	if fn.Name != "func" {
+		c.addError(node.Pos(), "not a function: %s", fn)
+		return UNKNOWN
+	}
+	return c.checkArguments(node, name, fn, args)
+}
+
+// checkIndex gives the type of an element of a value of type left, checking the index
+// node gives it is of the right type
+func (c *Checker) checkIndex(node *ast.IndexExpression, left *Type) *Type {
+	index := c.checkExpression(node.Index)
+	if left.IsUnknown() {
+		return UNKNOWN
+	}
+	if left.Name == "hashmap" {
+		if !Assignable(left.Key, index) {
+			c.addError(node.Index.Pos(), "can't use %s as key of %s", index, left)
+		}
+		return left.Elem
+	}
+	if left.Name != "array" && left != STRING {
+		c.addError(node.Pos(), "index operator not supported: %s", left)
+		return UNKNOWN
+	}
+	if !Assignable(INT, index) {
+		c.addError(node.Index.Pos(), "%s index must be int, not %s", left.Name, index)
+	}
+	if left == STRING {
+		return STRING
+	}
+	return left.Elem
+}
+
+func (c *Checker) checkArguments(node *ast.CallExpression, name string, fn *Type, args []*Type) *Type {
+	if len(args) != len(fn.Params) {
+		c.addError(node.Pos(), "wrong number of arguments to %s. got=%d, want=%d", name, len(args), len(fn.Params))
+		return fn.Return
+	}
+	for i, arg := range args {
+		if !Assignable(fn.Params[i], arg) {
+			c.addError(node.Arguments[i].Pos(), "argument %d to %s must be %s, not %s", i+1, name, fn.Params[i], arg)
+		}
+	}
+	return fn.Return
+}
@@ -0,0 +1,302 @@
+package typecheck
+
+import (
+	"testing"
+
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/parser"
+)
+
+func testCheck(t *testing.T, input string) []*Error {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return Check(program)
+}
+
+func TestWellTypedPrograms(t *testing.T) {
+	tests := []string{
+		"int x = 5; x = 10;",
+		"int i = 0; while(i < 10) { i = i + 1; if(i == 5) { break; } continue; }",
+		`hashmap(int, string) names = {1242: "Oisin"}; string n = names[1242]; array(int) ids = keys(names);`,
+		"hashmap(string, int) empty = {}; bool found = has_key(empty, \"a\");",
+		"float price = 9.99; float total = price * to_float(3) - 0.5; int whole = to_int(total);",
+		"bigint b = to_bigint(1) << to_bigint(70); b = -b % to_bigint(3); bool small = b < to_bigint(0); int i = to_int(~b);",
+		"array(int) xs = [1, 2, 3]; int y = xs[0] + len(xs);",
+		`string s = "a" + 1;`,
+		"func add(int x, int y): int { return x + y; } int z = add(1, 2);",
+		"func fib(int x): int { if(x < 2) { return x; } return fib(x - 1) + fib(x - 2); }",
+		"func first(): int { return later(); } func later(): int { return 1; } int a = first();",
+		"class A(string n) { func speak(): string { n; } } class B(string n) extends A { func speak(): string { super.speak() + n; } } A a = B(\"x\"); string s = a.speak();",
+		"class A() { } class B() extends A() { } func(B): int f = func(A a): int { return 1; }; func(): A g = func(): B { return B(); };",
+		"class A(int x) { } class B(int y) extends A(y + 1) { func sum(): int { return x + y; } } func make(): A { return B(1); } array(A) as = [make(), B(2)];",
+		"interface S { func area(): int; } class Q(int s) implements S { func area(): int { s * s; } } func big(S s): bool { s.area() > 10; } S s = Q(2); s = Q(5); bool b = big(s);",
+		"interface S { func area(): int; } class Q(int s) implements S { func area(): int { s; } } func make(): S { return Q(1); }",
+		"interface S { func area(): int; } class A(int s) { func area(): int { s; } } class B(int s) extends A implements S { } S s = B(1);",
+		`import "../testsuite/testdata/counter.azl" as c; int n = c.next() + c.count;`,
+		"class P(int x) { int y = x * 2; func move(int d): void { this.x = this.x + d; } func me(): P { return this; } } P p = P(1); p.move(2); int s = p.x + p.me().y;",
+		"array(int) r = range(10); for(i in r) { int j = i * 2; }",
+		"array(int) r = append(range(2), 5);",
+		`string s = "héllo"; string c = s[0] + s[1:] + s[:-1]; array(int) xs = [1, 2][1:]; int n = find(upper(s), "L") + ord(c[0]);`,
+		`array(string) parts = split("a,b", ","); string s = pad_left(join(parts, "-"), 5, ".") + substring(trim(" x "), 1) + repeat(chr(97), 2);`,
+		"func apply(func(int): int f, int x): int { return f(x); } int y = apply(func(int n): int { return n * 2; }, 2);",
+		"func counter(): func(): int { int n = 0; return func(): int { n += 1; return n; }; } func(): int c = counter(); int z = c() + counter()();",
+		"array(string) s = map([1, 2], func(int x): string { return \"n\" + x; }); array(int) evens = filter(range(10), func(int x): bool { return x % 2 == 0; });",
+		"int sum = reduce([1, 2], func(int a, int b): int { return a + b; }, 0); array(int) s = sort(reverse(slice([3, 1, 2], 1)), func(int a, int b): bool { return a > b; });",
+		"for(pair in zip([1], [2])) { int x = pair[0]; } hashmap(int, string) m = enumerate([\"a\"]); bool b = any([1], func(int x): bool { return true; }) && all([1], func(int x): bool { return true; }) && index_of([1], 1) == 0;",
+		"array(array(int)) pairs = zip([1, 2], [3, 4]); array(func(int): int) fs = [func(int x): int { return x; }]; int y = fs[0](pairs[1][0]); hashmap(string, array(int)) h = {\"a\": [1]};",
+		`string r = reverse("héllo"); int i = index_of(r, "l") + index_of([r], r);`,
+		"func(hashmap(string, int), array(int)): bool f = func(hashmap(string, int) m, array(int) xs): bool { return len(m) == len(xs); };",
+		"int x = 7; bool b = x > 3 && x <= 10 || !(x >= 2); int y = x % 3 + (x & 1 | 2 ^ 3) + (1 << 4 >> 2) + ~x;",
+		"bool b = 1.5 <= 2.5;",
+		`int x = 1; x += 2; x++; float f = 1.0; f /= 2.0; string s = "a"; s += 1;`,
+		`array(int) xs = [1, 2]; xs[0] = 3; xs[1] *= 2; hashmap(string, int) m = {}; m["a"] = 1; m["a"]++;`,
+		"class P(int x) { func bump(): void { this.x += 1; x--; } } P p = P(1); p.x %= 2;",
+		`try { int n = to_int("x"); } catch (ConversionError e) { string m = e.message; array(string) s = e.stack; } finally { print("done"); }`,
+		`func check(int x): int { if(x < 0) { throw error("ValueError", "negative"); } return x; } error e = error("x"); throw e;`,
+		`class Point(int x, int y) {
+			func getx(): int {
+				return x;
+			}
+		}
+		Point p = Point(1, 2);
+		int px = p.getx();`,
+	}
+
+	for _, input := range tests {
+		errors := testCheck(t, input)
+		if len(errors) != 0 {
+			t.Errorf("unexpected type errors for %q: %v", input, errors)
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`int x = "a";`, "trying to assign string to int x"},
+		{`if(false) { int x = "a"; }`, "trying to assign string to int x"},
+		{"array(string) xs = [1, 2];", "trying to assign array(int) to array(string) xs"},
+		{"[1, true];", "trying to assign bool to array of int"},
+		{`int x = 5; x = "a";`, "can't assign value of type string to variable of type int"},
+		{"y = 5;", "can't reassign value to non-existent variable 'y'"},
+		{"foobar;", "identifier not found: foobar"},
+		{"Thing t = 5;", "unknown type Thing"},
+		{`func f(int x): int { return "a"; }`, "returning string from function that returns int"},
+		{"func f(int x): int { return x; } f(1, 2);", "wrong number of arguments to f. got=2, want=1"},
+		{`func f(int x): int { return x; } f("a");`, "argument 1 to f must be int, not string"},
+		{"func f(): void { 1; } int x = f();", "trying to assign void to int x"},
+		{"func f(): void { return 1; }", "returning int from function that returns void"},
+		{"int a = later(); func later(): int { return 1; }", "later is used before its definition"},
+		{"print(f(1)); func f(int a): int { return a; }", "f is used before its definition"},
+		{"A a = A(); class A() { }", "A is used before its definition"},
+		{"class B() extends A() { } class A() { }", "A is used before its definition"},
+		{"class Q() implements S { } interface S { }", "S is used before its definition"},
+		{"class P() { int n = m(); func m(): int { return 1; } }", "m is used before its definition"},
+		{"range(1, 2, 3);", "wrong number of arguments to range. got=3, want 1 to 2"},
+		{"true - 1;", "type mismatch: bool - int"},
+		{"1 + 2.5;", "type mismatch: int + float"},
+		{"1 && true;", "type mismatch: int && bool"},
+		{`true || "a";`, "type mismatch: bool || string"},
+		{`"a" <= "b";`, "type mismatch: string <= string"},
+		{"1.5 % 2.0;", "unknown operator: float % float"},
+		{"1 << 2.0;", "type mismatch: int << float"},
+		{"true & false;", "type mismatch: bool & bool"},
+		{"~1.5;", "unknown operator: ~float"},
+		{"to_bigint(1) + 1;", "type mismatch: bigint + int"},
+		{"to_bigint(1) == 1;", "type mismatch: bigint == int"},
+		{"bigint b = 5;", "trying to assign int to bigint b"},
+		{"func apply(func(int): int f): int { return f(1); } apply(func(string s): int { return 1; });", "argument 1 to apply must be func(int): int, not func(string): int"},
+		{"func(int): int f = func(int x): bool { return true; };", "trying to assign func(int): bool to func(int): int f"},
+		{`func(int): int f = func(int x): int { return x; }; f("a");`, "argument 1 to f must be int, not string"},
+		{"array(string) s = map([1], func(int x): int { return x; });", "trying to assign array(int) to array(string) s"},
+		{"filter([1], func(int x): int { return x; });", "argument 2 to filter must be func(int): bool, not func(int): int"},
+		{`map(["a"], func(int x): int { return x; });`, "argument 2 to map must be func(string): int, not func(int): int"},
+		{"reduce([1], func(int a, int b): int { return a + b; }, 0.5);", "argument 2 to reduce must be func(float, int): float, not func(int, int): int"},
+		{`array(array(string)) ps = zip([1], [2]);`, "trying to assign array(array(int)) to array(array(string)) ps"},
+		{"array(func(int): int) fs = [func(int x): bool { return true; }];", "trying to assign array(func(int): bool) to array(func(int): int) fs"},
+		{`zip([1], ["a"]);`, "argument 2 to zip must be array(int), not array(string)"},
+		{`index_of("abc", 1);`, "argument 2 to index_of must be string, not int"},
+		{"int r = reverse([1]);", "trying to assign array(int) to int r"},
+		{"slice([1], 0, 1.5);", "argument 3 to slice must be int, not float"},
+		{"func(): int f = func(): int { return true; };", "returning bool from function that returns int"},
+		{"func(foo): int f = len;", "unknown type foo"},
+		{"int x = 1; x += 1.5;", "type mismatch: int + float"},
+		{`int x = 1; x += "a";`, "can't assign value of type string to variable of type int"},
+		{"bool b = true; b--;", "type mismatch: bool - int"},
+		{"y += 1;", "can't reassign value to non-existent variable 'y'"},
+		{`array(int) xs = [1]; xs[0] = "a";`, "can't assign value of type string to element of type int"},
+		{`array(int) xs = [1]; xs["a"] = 1;`, "array index must be int, not string"},
+		{`"abc"["a"];`, "string index must be int, not string"},
+		{`string s = "${print(1)}";`, "can't put void in a string"},
+		{`int n = "${1}";`, "trying to assign string to int n"},
+		{`string s = "ab"; s[0] = "c";`, "can't assign to a character of a string"},
+		{`"abc"[1:"a"];`, "slice bounds must be int, not string"},
+		{"5[1:];", "slice operator not supported: int"},
+		{`int n = find("a", 1);`, "argument 2 to find must be string, not int"},
+		{`join([1], "");`, "argument 1 to join must be array(string), not array(int)"},
+		{`hashmap(string, int) m = {}; m[1] = 1;`, "can't use int as key of hashmap(string, int)"},
+		{"int x = 1; x[0] = 1;", "index operator not supported: int"},
+		{"class P(int x) { } P p = P(1); p.x *= 1.5;", "type mismatch: int * float"},
+		{"while(1) { }", "while condition must be bool, not int"},
+		{"break;", "break outside of a loop"},
+		{"while(true) { func f(): void { continue; } }", "continue outside of a loop"},
+		{`hashmap(int, string) m = {"a": "b"};`, "trying to assign hashmap(string, string) to hashmap(int, string) m"},
+		{`hashmap(int, string) m = {1: "a"}; m["a"];`, "can't use string as key of hashmap(int, string)"},
+		{`{1: "a", 2: 3};`, "trying to use int as value of hashmap(int, string)"},
+		{`{[1]: 1};`, "unusable as hashmap key: array(int)"},
+		{"float f = 1;", "trying to assign int to float f"},
+		{"int i = to_float(1) * 2.0;", "trying to assign float to int i"},
+		{"5[0];", "index operator not supported: int"},
+		{"for(i in 5) { i; }", "iterator must be an array, not int"},
+		{"class C(int x) { } C c = C(); ", "wrong number of arguments to C. got=0, want=1"},
+		{"class C(int x) { } C c = C(1); c.missing();", "class C has no method missing"},
+		{"class C(int x) { } C c = C(1); c.y;", "class C has no field y"},
+		{"class C(int x) { } C c = C(1); c.x = \"s\";", "can't assign value of type string to field x of type int"},
+		{"class C(int x) { } C c = C(1); string s = c.x;", "trying to assign int to string s"},
+		{"int x = 1; x.y;", "can't get field y of int, it is not an object"},
+		{"this;", "this outside of a class"},
+		{"class A() { } class B() extends A { } B b = A();", "trying to assign A to B b"},
+		{"class A(int x) { } class B(string s) extends A { }", "argument 1 to A must be int, not string"},
+		{"class A(int x) { } class B() extends A(1, 2) { }", "wrong number of arguments to A. got=2, want=1"},
+		{"class B() extends Nope { }", "Nope is not a class"},
+		{"class A() { func f(): int { return super.f(); } }", "super outside of a subclass"},
+		{`import "../testsuite/testdata/counter.azl" as c; string s = c.next();`, "trying to assign int to string s"},
+		{`import "../testsuite/testdata/counter.azl" as c; c.next(1);`, "wrong number of arguments to next. got=1, want=0"},
+		{`import "../testsuite/testdata/counter.azl" as c; int n = c._secret;`, "module counter doesn't export _secret"},
+		{`import "../testsuite/testdata/counter.azl" as c; c.count = 2;`, "can't assign to count of module counter"},
+		{`import "../testsuite/testdata/counter.azl" as c; next();`, "identifier not found: next"},
+		{`import "../testsuite/testdata/cycle_a.azl";`, "import cycle: cycle_a.azl -> cycle_b.azl -> cycle_a.azl"},
+		{"interface S { func area(): int; } class Q() implements S { }", "class Q doesn't implement S: missing method area"},
+		{"interface S { func area(int x): int; } class Q() implements S { func area(): int { 1; } }", "class Q doesn't implement S: method area is func(): int, not func(int): int"},
+		{"class A() { } class Q() implements A { }", "A is not an interface"},
+		{"interface S { func area(): int; } class Q() { } S s = Q();", "trying to assign Q to S s"},
+		{"interface S { func area(): int; } func f(S s): int { s.size(); }", "interface S has no method size"},
+		{"throw 5;", "can only throw a string or an error, not int"},
+		{`try { 1; } catch (e) { int n = e.message; }`, "trying to assign string to int n"},
+		{`try { 1; } catch (e) { e.line; }`, "error has no field line"},
+		{`try { 1; } catch (e) { e.kind = "x"; }`, "can't assign to kind of an error"},
+		{`try { 1; } catch (e) { } e.message;`, "identifier not found: e"},
+		{`int e = 5; try { 1; } catch (e) { } string s = e;`, "trying to assign int to string s"},
+		{`error e = error(1);`, "argument 1 to error must be string, not int"},
+		{"class A() { } class B() extends A() { func only(): int { return 1; } } func put(array(A) xs): void { xs[0] = A(); } array(B) bs = [B()]; put(bs);", "argument 1 to put must be array(A), not array(B)"},
+		{"class A() { } class B() extends A() { } array(B) bs = [B()]; array(A) as = bs;", "trying to assign array(B) to array(A) as"},
+		{"class A() { } class B() extends A() { } array(A) as = [B()];", "trying to assign array(B) to array(A) as"},
+		{"class A() { } class B() extends A() { } func(A): int f = func(B b): int { return 1; };", "trying to assign func(B): int to func(A): int f"},
+		{`class A() { } class B() extends A() { } hashmap(string, A) m = {"k": B()};`, "trying to assign hashmap(string, B) to hashmap(string, A) m"},
+		{`class A() { } class B() extends A() { } func f(): hashmap(string, B) { return {"k": B()}; } hashmap(string, A) m = f();`, "trying to assign hashmap(string, B) to hashmap(string, A) m"},
+	}
+
+	for _, tt := range tests {
+		errors := testCheck(t, tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q. got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errors[0].Message)
+		}
+	}
+}
+
+func TestReportsAllErrors(t *testing.T) {
+	input := `int x = "a";
+string y = 5;
+z;`
+
+	errors := testCheck(t, input)
+	if len(errors) != 3 {
+		t.Fatalf("expected 3 errors. got=%d (%v)", len(errors), errors)
+	}
+
+	for i, line := range []int{1, 2, 3} {
+		if errors[i].Pos.Line != line {
+			t.Errorf("errors[%d] has wrong line. expected=%d, got=%d", i, line, errors[i].Pos.Line)
+		}
+	}
+
+	// a cycle of classes always uses one of them before its definition as well
+	errors = testCheck(t, "class A() extends B { } class B() extends A { }")
+	expected := []string{"class B can't extend A, which inherits from it", "B is used before its definition"}
+	if len(errors) != len(expected) {
+		t.Fatalf("expected %d errors. got=%d (%v)", len(expected), len(errors), errors)
+	}
+	for i, msg := range expected {
+		if errors[i].Message != msg {
+			t.Errorf("errors[%d] has wrong message. expected=%q, got=%q", i, msg, errors[i].Message)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	parse := func(input string) *ast.Program {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors())
+		}
+		return program
+	}
+
+	c := New()
+	c.Check(parse("int x = 5; func f(int a): string { return \"\"; }"))
+
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{"x;", "int", ""},
+		{"f(x);", "string", ""},
+		{"x++;", "void", ""},
+		{"class P() { } P();", "P", ""},
+		{"int y = 1; [y];", "array(int)", ""},
+		{"x + 1.0;", "unknown", "type mismatch: int + float"},
+	}
+
+	for _, tt := range tests {
+		typ, errors := c.TypeOf(parse(tt.input))
+		if tt.err != "" {
+			if len(errors) != 1 || errors[0].Message != tt.err {
+				t.Errorf("%q: expected error %q. got=%v", tt.input, tt.err, errors)
+			}
+			continue
+		}
+		if len(errors) != 0 {
+			t.Errorf("%q: unexpected errors %v", tt.input, errors)
+		}
+		if typ.String() != tt.expected {
+			t.Errorf("%q: wrong type. expected=%s, got=%s", tt.input, tt.expected, typ)
+		}
+	}
+
+	// nothing the expressions defined is kept, nor are their errors
+	c.Check(parse("y; P();"))
+	if len(c.Errors()) != 2 {
+		t.Errorf("expected y and P to be undefined. got=%v", c.Errors())
+	}
+}
+
+func TestExtend(t *testing.T) {
+	c := New()
+	for _, input := range []string{"int x = 1;", "int y = \"a\"; class A() { }", "x + 1;"} {
+		c.Extend(parser.New(lexer.New(input)).ParseProgram())
+	}
+
+	errors := c.Extend(parser.New(lexer.New("y; A();")).ParseProgram())
+	if len(errors) != 2 {
+		t.Fatalf("expected y and A to be undefined. got=%v", errors)
+	}
+	if len(c.Errors()) != 0 {
+		t.Errorf("errors were kept. got=%v", c.Errors())
+	}
+	if errors := c.Extend(parser.New(lexer.New("string s = x;")).ParseProgram()); len(errors) != 1 || errors[0].Message != "trying to assign int to string s" {
+		t.Errorf("x isn't an int. got=%v", errors)
+	}
+}
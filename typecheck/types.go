@@ -0,0 +1,123 @@
+package typecheck
+
+import (
+	"strings"
+)
+
+// Type is the static type of a value
+type Type struct {
+	Name       string
+	Key        *Type            // key type of a hashmap
+	Elem       *Type            // element type of an array, or value type of a hashmap
+	Params     []*Type          // parameter types of a function
+	Return     *Type            // return type of a function
+	Parent     *Type            // class a class inherits from
+	Interfaces []*Type          // interfaces a class implements
+	Module     string           // name of a module
+	Members    map[string]*Type // names exported by a module
+}
+
+var (
+	UNKNOWN = &Type{Name: "unknown"}
+	INT     = &Type{Name: "int"}
+	FLOAT   = &Type{Name: "float"}
+	BIGINT  = &Type{Name: "bigint"}
+	BOOL    = &Type{Name: "bool"}
+	STRING  = &Type{Name: "string"}
+	VOID    = &Type{Name: "void"}
+	ERROR   = &Type{Name: "error"}
+)
+
+// errorFields are the fields of an error caught by a try statement
+var errorFields = map[string]*Type{
+	"message": STRING,
+	"kind":    STRING,
+	"stack":   ArrayOf(STRING),
+}
+
+// ArrayOf gives the type of an array holding elements of the given type
+func ArrayOf(elem *Type) *Type {
+	return &Type{Name: "array", Elem: elem}
+}
+
+// HashmapOf gives the type of a hashmap from keys of one type to values of another
+func HashmapOf(key *Type, value *Type) *Type {
+	return &Type{Name: "hashmap", Key: key, Elem: value}
+}
+
+// FunctionOf gives the type of a function with the given parameter and return types
+func FunctionOf(params []*Type, ret *Type) *Type {
+	return &Type{Name: "func", Params: params, Return: ret}
+}
+
+func (t *Type) String() string {
+	switch t.Name {
+	case "array":
+		return "array(" + t.Elem.String() + ")"
+	case "hashmap":
+		return "hashmap(" + t.Key.String() + ", " + t.Elem.String() + ")"
+	case "func":
+		params := []string{}
+		for _, p := range t.Params {
+			params = append(params, p.String())
+		}
+		return "func(" + strings.Join(params, ", ") + "): " + t.Return.String()
+	case "module":
+		return "module " + t.Module
+	default:
+		return t.Name
+	}
+}
+
+// IsUnknown reports whether the type couldn't be worked out statically
+func (t *Type) IsUnknown() bool {
+	return t == nil || t.Name == UNKNOWN.Name
+}
+
+// Assignable reports whether a value of type from can be stored where type to is expected.
+// Unknown types are assignable to and from anything. Arrays and hashmaps can be changed
+// through any variable holding them, so what they hold has to be exactly the type expected.
+func Assignable(to *Type, from *Type) bool {
+	if to.IsUnknown() || from.IsUnknown() {
+		return true
+	}
+	if to.Name != from.Name {
+		// an instance of a subclass can be used where its parent, or an interface
+		// it or its parent implements, is expected
+		for class := from; class != nil; class = class.Parent {
+			if class != from && class.Name == to.Name {
+				return true
+			}
+			for _, iface := range class.Interfaces {
+				if iface.Name == to.Name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch to.Name {
+	case "array":
+		return identical(to.Elem, from.Elem)
+	case "hashmap":
+		return identical(to.Key, from.Key) && identical(to.Elem, from.Elem)
+	case "func":
+		if len(to.Params) != len(from.Params) {
+			return false
+		}
+		// a function is called with what the type it's stored as takes, so it has
+		// to take at least that
+		for i := range to.Params {
+			if !Assignable(from.Params[i], to.Params[i]) {
+				return false
+			}
+		}
+		return Assignable(to.Return, from.Return)
+	}
+	return true
+}
+
+// identical reports whether two types are the same, treating unknown as any type
+func identical(a *Type, b *Type) bool {
+	return Assignable(a, b) && Assignable(b, a)
+}
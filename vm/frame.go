@@ -0,0 +1,33 @@
+package vm
+
+import (
+	"github.com/OisinA/Azula/code"
+	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/token"
+)
+
+// Frame is a call to a closure, along with the instance it was called on if it is a method
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int
+	receiver    *object.Instance
+}
+
+func NewFrame(cl *object.Closure, basePointer int, receiver *object.Instance) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer, receiver: receiver}
+}
+
+// position gives where in the source the instruction the frame is running came from
+func (f *Frame) position() token.Position {
+	for ip := f.ip; ip >= 0; ip-- {
+		if pos, ok := f.cl.Fn.Positions[ip]; ok {
+			return pos
+		}
+	}
+	return token.Position{}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
@@ -0,0 +1,756 @@
+package vm
+
+import (
+	"github.com/OisinA/Azula/ast"
+	"github.com/OisinA/Azula/code"
+	"github.com/OisinA/Azula/compiler"
+	"github.com/OisinA/Azula/evaluator"
+	"github.com/OisinA/Azula/object"
+)
+
+const StackSize = 16384
+const GlobalsSize = 65536
+
+var (
+	TRUE  = evaluator.TRUE
+	FALSE = evaluator.FALSE
+	NULL  = evaluator.NULL
+)
+
+// VM runs the bytecode made by the compiler
+type VM struct {
+	constants []object.Object
+	nodes     []ast.Node
+
+	stack []object.Object
+	sp    int // always points to the next free slot, the top of the stack is stack[sp-1]
+
+	globals []object.Object
+	// modules holds the modules that have been imported, by the constant they were compiled into
+	modules map[*object.Module]*object.Module
+
+	frames      []*Frame
+	framesIndex int
+
+	// openCells are the cells closures share with functions that are still running,
+	// by the slot on the stack they refer to
+	openCells map[int]*object.Cell
+
+	handlers []handler
+
+	// ctx is what builtins are called with
+	ctx *object.Context
+	// limits bound the calls the program can be inside at once, and the instructions it runs
+	limits *object.Limits
+}
+
+// handler is an open try statement, which errors unwind the stack back to
+type handler struct {
+	catchIP     int
+	framesIndex int
+	sp          int
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobalsStore(bytecode, make([]object.Object, GlobalsSize))
+}
+
+// NewWithGlobalsStore gives a vm that shares its globals with earlier runs
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	mainClosure := &object.Closure{Fn: bytecode.Main}
+	mainFrame := NewFrame(mainClosure, 0, nil)
+
+	frames := []*Frame{mainFrame}
+
+	vm := &VM{
+		constants:   bytecode.Constants,
+		nodes:       bytecode.Nodes,
+		stack:       make([]object.Object, StackSize),
+		sp:          0,
+		globals:     globals,
+		modules:     make(map[*object.Module]*object.Module),
+		frames:      frames,
+		framesIndex: 1,
+		openCells:   make(map[int]*object.Cell),
+		limits:      &object.Limits{MaxDepth: object.DEFAULT_MAX_DEPTH},
+	}
+	vm.SetContext(object.StdioContext())
+	return vm
+}
+
+// SetContext changes the streams the program's builtins read and write
+func (vm *VM) SetContext(ctx *object.Context) {
+	vm.ctx = ctx.WithCall(vm.callFunction)
+}
+
+// SetLimits changes the limits on the program, which count steps as instructions run
+func (vm *VM) SetLimits(limits *object.Limits) {
+	vm.limits = limits
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.framesIndex] = f
+	}
+	vm.framesIndex++
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+// Run runs the program, giving the value of its last statement or the error that stopped it
+func (vm *VM) Run() object.Object {
+	return vm.run(0)
+}
+
+// run runs instructions until the frame above base returns, giving the value it
+// returns, or until an error raised above base isn't caught there. The main frame is
+// above a base of 0, so running from there runs the whole program.
+func (vm *VM) run(base int) object.Object {
+	var ip int
+	var ins code.Instructions
+	var op code.Opcode
+
+	for {
+		frame := vm.currentFrame()
+		frame.ip++
+		ip = frame.ip
+		ins = frame.Instructions()
+		op = code.Opcode(ins[ip])
+
+		var err object.Object
+		if limits := vm.limits; limits.MaxSteps > 0 {
+			limits.Steps++
+			if limits.Steps > limits.MaxSteps {
+				if e := vm.fail(evaluator.StepLimitError(limits.MaxSteps), frame, ip); !vm.catch(e, base) {
+					return e
+				}
+				continue
+			}
+		}
+
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = vm.push(vm.constants[constIndex])
+
+		case code.OpPop:
+			vm.pop()
+
+		case code.OpTrue:
+			err = vm.push(TRUE)
+
+		case code.OpFalse:
+			err = vm.push(FALSE)
+
+		case code.OpNull:
+			err = vm.push(NULL)
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+			code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan,
+			code.OpGreaterEqual, code.OpLessEqual, code.OpMod,
+			code.OpBitAnd, code.OpBitOr, code.OpBitXor, code.OpShiftLeft, code.OpShiftRight:
+			right := vm.pop()
+			left := vm.pop()
+			err = vm.push(vm.executeBinaryOperation(op, left, right))
+
+		case code.OpMinus:
+			err = vm.push(evaluator.EvalPrefixExpression("-", vm.pop()))
+
+		case code.OpBang:
+			err = vm.push(evaluator.EvalPrefixExpression("!", vm.pop()))
+
+		case code.OpBitNot:
+			err = vm.push(evaluator.EvalPrefixExpression("~", vm.pop()))
+
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip = pos - 1
+
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			if !evaluator.IsTruthy(vm.pop()) {
+				frame.ip = pos - 1
+			}
+
+		case code.OpGetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = vm.push(vm.globals[globalIndex])
+
+		case code.OpSetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			vm.globals[globalIndex] = vm.pop()
+
+		case code.OpGetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			err = vm.push(vm.stack[frame.basePointer+int(localIndex)])
+
+		case code.OpSetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			vm.stack[frame.basePointer+int(localIndex)] = vm.pop()
+
+		case code.OpGetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			err = vm.push(*frame.cl.Free[freeIndex].Ref)
+
+		case code.OpSetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			*frame.cl.Free[freeIndex].Ref = vm.pop()
+
+		case code.OpGetLocalCell:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			err = vm.push(vm.cell(frame.basePointer + int(localIndex)))
+
+		case code.OpGetFreeCell:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			err = vm.push(frame.cl.Free[freeIndex])
+
+		case code.OpCurrentClosure:
+			err = vm.push(frame.cl)
+
+		case code.OpGetField:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			frame.ip += 2
+			err = vm.getField(frame.receiver, name)
+
+		case code.OpSetField:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			frame.ip += 2
+			if frame.receiver == nil {
+				err = evaluator.NewError("can't set %s outside of an object", name)
+				break
+			}
+			frame.receiver.Set(name, vm.pop())
+
+		case code.OpDefineField:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			frame.ip += 2
+			if frame.receiver == nil {
+				err = evaluator.NewError("can't set %s outside of an object", name)
+				break
+			}
+			frame.receiver.Env.Set(name, vm.pop())
+
+		case code.OpGetMethod:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			frame.ip += 2
+			err = vm.push(evaluator.GetMethod(vm.pop(), name))
+
+		case code.OpGetProperty:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			frame.ip += 2
+			err = vm.push(evaluator.GetField(vm.pop(), name))
+
+		case code.OpSetProperty:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			frame.ip += 2
+			obj := vm.pop()
+			if e := evaluator.SetField(obj, name, vm.pop()); e != nil {
+				err = e
+			}
+
+		case code.OpReceiver:
+			err = vm.push(frame.receiver)
+
+		case code.OpThis:
+			if frame.receiver == nil {
+				err = evaluator.NewError("this outside of a class")
+				break
+			}
+			err = vm.push(frame.receiver.This)
+
+		case code.OpGetSuper:
+			if frame.receiver == nil || frame.receiver.Super == nil {
+				err = evaluator.NewError("super outside of a subclass")
+				break
+			}
+			err = vm.push(frame.receiver.Super)
+
+		case code.OpSuper:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			err = vm.callSuper(frame.receiver, int(numArgs))
+
+		case code.OpSetSuper:
+			frame.receiver.Super = vm.pop().(*object.Instance)
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			elements := make([]object.Object, numElements)
+			copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+			vm.sp = vm.sp - numElements
+			err = vm.push(evaluator.NewArray(elements))
+
+		case code.OpInterpolate:
+			numParts := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			parts := make([]object.Object, numParts)
+			copy(parts, vm.stack[vm.sp-numParts:vm.sp])
+			vm.sp = vm.sp - numParts
+			err = vm.push(evaluator.Interpolate(parts))
+
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			keys := []object.Object{}
+			values := []object.Object{}
+			for i := vm.sp - numElements; i < vm.sp; i += 2 {
+				keys = append(keys, vm.stack[i])
+				values = append(values, vm.stack[i+1])
+			}
+			vm.sp = vm.sp - numElements
+			err = vm.push(evaluator.NewHash(keys, values))
+
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.push(evaluator.EvalIndexExpression(left, index))
+
+		case code.OpSlice:
+			end := vm.pop()
+			start := vm.pop()
+			left := vm.pop()
+			err = vm.push(evaluator.EvalSliceExpression(left, start, end))
+
+		case code.OpSetIndex:
+			index := vm.pop()
+			left := vm.pop()
+			if e := evaluator.SetIndex(left, index, vm.pop()); e != nil {
+				err = e
+			}
+
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+			err = vm.executeCall(int(numArgs))
+
+		case code.OpReturnValue, code.OpReturn:
+			var returnValue object.Object = NULL
+			if op == code.OpReturnValue {
+				returnValue = vm.pop()
+			}
+			if vm.framesIndex == 1 {
+				return returnValue
+			}
+
+			fn := frame.cl.Fn
+			if fn.ReturnType != nil {
+				returnValue = evaluator.CheckReturnType(fn.Name, fn.ReturnType, returnValue)
+				if returnValue.Type() == object.ERROR_OBJ {
+					err = returnValue
+					break
+				}
+			}
+
+			// trys the function returned from inside of are no longer open
+			for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].framesIndex == vm.framesIndex {
+				vm.handlers = vm.handlers[:len(vm.handlers)-1]
+			}
+			vm.popFrame()
+			vm.closeCells(frame.basePointer)
+			vm.sp = frame.basePointer - 1
+			if vm.framesIndex == base {
+				return returnValue
+			}
+			err = vm.push(returnValue)
+
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			numFree := code.ReadUint8(ins[ip+3:])
+			frame.ip += 3
+			err = vm.pushClosure(int(constIndex), int(numFree), frame.receiver)
+
+		case code.OpClass:
+			node := vm.nodes[code.ReadUint16(ins[ip+1:])].(*ast.ClassLiteral)
+			frame.ip += 2
+			class := &object.Class{Name: node.Name, Parameters: node.Parameters, Body: node.Body, ParentArguments: node.ParentArguments}
+			class.Interfaces = make([]*object.Interface, len(node.Interfaces))
+			for i := len(node.Interfaces) - 1; i >= 0; i-- {
+				iface, ok := vm.pop().(*object.Interface)
+				if !ok {
+					err = evaluator.NewError("%s is not an interface", node.Interfaces[i].Value)
+					break
+				}
+				class.Interfaces[i] = iface
+			}
+			if err != nil {
+				break
+			}
+			if node.Parent != nil {
+				parent, ok := vm.pop().(*object.Class)
+				if !ok {
+					err = evaluator.NewError("%s is not a class", node.Parent.Value)
+					break
+				}
+				class.Parent = parent
+			}
+			class.Constructor = vm.pop().(*object.Closure)
+			if e := evaluator.CheckImplements(class); e != nil {
+				err = e
+				break
+			}
+			err = vm.push(class)
+
+		case code.OpImport:
+			mod := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.Module)
+			frame.ip += 2
+			if loaded, ok := vm.modules[mod]; ok {
+				err = vm.push(loaded)
+				break
+			}
+			// the first import runs the module, which ends by importing itself
+			vm.modules[mod] = &object.Module{Name: mod.Name, File: mod.File, Slots: mod.Slots, Globals: vm.globals}
+			init := &object.Closure{Fn: mod.Init}
+			if err = vm.push(init); err == nil {
+				err = vm.callClosure(init, 0, nil)
+			}
+
+		case code.OpCheckLet:
+			node := vm.nodes[code.ReadUint16(ins[ip+1:])].(*ast.LetStatement)
+			frame.ip += 2
+			if e := evaluator.CheckLet(node, vm.stack[vm.sp-1]); e != nil {
+				err = e
+			}
+
+		case code.OpCheckReassign:
+			val := vm.pop()
+			old := vm.pop()
+			if e := evaluator.CheckReassign(old, val); e != nil {
+				err = e
+				break
+			}
+			err = vm.push(val)
+
+		case code.OpCheckIterable:
+			if vm.stack[vm.sp-1].Type() != object.ARRAY_OBJ {
+				err = evaluator.NewError("iterator must be an array")
+			}
+
+		case code.OpIterNext:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			index := vm.pop().(*object.Integer).Value
+			array := vm.pop().(*object.Array)
+			if int(index) >= len(array.Elements) {
+				frame.ip = pos - 1
+				break
+			}
+			err = vm.push(array.Elements[index])
+
+		case code.OpTry:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			vm.handlers = append(vm.handlers, handler{catchIP: pos, framesIndex: vm.framesIndex, sp: vm.sp})
+
+		case code.OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case code.OpThrow:
+			err = evaluator.Throw(vm.pop())
+
+		case code.OpCatch:
+			clause := vm.nodes[code.ReadUint16(ins[ip+1:])].(*ast.CatchClause)
+			frame.ip += 2
+			raised := vm.stack[vm.sp-1].(*object.ErrorValue)
+			err = vm.push(nativeBoolToBooleanObject(evaluator.CatchMatches(clause, raised.Error)))
+		}
+
+		if err != nil && err.Type() == object.ERROR_OBJ {
+			if e := vm.fail(err.(*object.Error), frame, ip); !vm.catch(e, base) {
+				return e
+			}
+		}
+	}
+}
+
+// fail fills in where an error happened, from the instruction that caused it
+func (vm *VM) fail(err *object.Error, frame *Frame, ip int) *object.Error {
+	if !err.Pos.IsValid() {
+		err.Pos = frame.cl.Fn.Positions[ip]
+	}
+	return err
+}
+
+// catch unwinds the stack to the innermost open try and carries on from its handler,
+// first giving the error the calls it was raised in. It reports false if there is no
+// try above base to catch the error.
+func (vm *VM) catch(err *object.Error, base int) bool {
+	if err.Stack == nil {
+		err.Stack = vm.callStack()
+	}
+	if len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].framesIndex <= base {
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.framesIndex = h.framesIndex
+	vm.closeCells(h.sp)
+	vm.sp = h.sp
+	vm.push(&object.ErrorValue{Error: err})
+	vm.currentFrame().ip = h.catchIP - 1
+	return true
+}
+
+// callStack gives the calls the vm is in the middle of, outermost first. The main
+// frame isn't a call, and each frame was called from where the one below it is.
+func (vm *VM) callStack() []object.Call {
+	calls := make([]object.Call, 0, vm.framesIndex-1)
+	for i := 1; i < vm.framesIndex; i++ {
+		calls = append(calls, object.Call{Function: vm.frames[i].cl.Fn.Name, Site: vm.frames[i-1].position()})
+	}
+	return calls
+}
+
+func (vm *VM) push(o object.Object) object.Object {
+	if o != nil && o.Type() == object.ERROR_OBJ {
+		return o
+	}
+	if vm.sp >= StackSize {
+		return evaluator.NewError("stack overflow")
+	}
+	if o == nil {
+		o = NULL
+	}
+
+	vm.stack[vm.sp] = o
+	vm.sp++
+
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+func (vm *VM) executeBinaryOperation(op code.Opcode, left object.Object, right object.Object) object.Object {
+	// integers are the common case, so they skip the evaluator's dispatch on the operator
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			switch op {
+			case code.OpAdd:
+				return evaluator.AddIntegers(l.Value, r.Value)
+			case code.OpSub:
+				return evaluator.SubtractIntegers(l.Value, r.Value)
+			case code.OpMul:
+				return evaluator.MultiplyIntegers(l.Value, r.Value)
+			case code.OpEqual:
+				return nativeBoolToBooleanObject(l.Value == r.Value)
+			case code.OpNotEqual:
+				return nativeBoolToBooleanObject(l.Value != r.Value)
+			case code.OpGreaterThan:
+				return nativeBoolToBooleanObject(l.Value > r.Value)
+			case code.OpLessThan:
+				return nativeBoolToBooleanObject(l.Value < r.Value)
+			case code.OpGreaterEqual:
+				return nativeBoolToBooleanObject(l.Value >= r.Value)
+			case code.OpLessEqual:
+				return nativeBoolToBooleanObject(l.Value <= r.Value)
+			}
+		}
+	}
+
+	return evaluator.EvalInfixExpression(operators[op], left, right)
+}
+
+var operators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpGreaterThan:  ">",
+	code.OpLessThan:     "<",
+	code.OpGreaterEqual: ">=",
+	code.OpLessEqual:    "<=",
+	code.OpMod:          "%",
+	code.OpBitAnd:       "&",
+	code.OpBitOr:        "|",
+	code.OpBitXor:       "^",
+	code.OpShiftLeft:    "<<",
+	code.OpShiftRight:   ">>",
+}
+
+func (vm *VM) getField(receiver *object.Instance, name string) object.Object {
+	if receiver != nil {
+		if val, ok := receiver.Get(name); ok {
+			return vm.push(val)
+		}
+	}
+	return evaluator.NewError("identifier not found: %s", name)
+}
+
+func (vm *VM) executeCall(numArgs int) object.Object {
+	callee := vm.stack[vm.sp-1-numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs, callee.Receiver)
+	case *object.Builtin:
+		return vm.callBuiltin(callee, numArgs)
+	case *object.Class:
+		return vm.callClass(callee, numArgs)
+	default:
+		return evaluator.NewError("not a function: %s", callee.Type())
+	}
+}
+
+func (vm *VM) callClosure(cl *object.Closure, numArgs int, receiver *object.Instance) object.Object {
+	if err := evaluator.CheckArity(cl.Fn.Name, numArgs, cl.Fn.NumParameters); err != nil {
+		return err
+	}
+	// the main frame is below the frame of every call
+	depth := vm.framesIndex - 1
+	if max := vm.limits.MaxDepth; (max > 0 && depth >= max) || vm.sp-numArgs+cl.Fn.NumLocals >= StackSize {
+		return evaluator.RecursionError(cl.Fn.Name)
+	}
+
+	frame := NewFrame(cl, vm.sp-numArgs, receiver)
+	vm.pushFrame(frame)
+
+	vm.sp = frame.basePointer + cl.Fn.NumLocals
+	return nil
+}
+
+// callFunction calls a function for a builtin, running it to completion before the
+// builtin carries on. An error the function doesn't catch is given back to the
+// builtin, with the stack unwound to where it was called from.
+func (vm *VM) callFunction(fn object.Object, args ...object.Object) object.Object {
+	base, sp := vm.framesIndex, vm.sp
+	result := vm.push(fn)
+	for _, arg := range args {
+		if result == nil {
+			result = vm.push(arg)
+		}
+	}
+	if result == nil {
+		result = vm.executeCall(len(args))
+	}
+	if result == nil {
+		if vm.framesIndex == base {
+			// builtins give their result without a frame of their own
+			result = vm.pop()
+		} else {
+			result = vm.run(base)
+		}
+	}
+	if result.Type() == object.ERROR_OBJ {
+		vm.framesIndex = base
+		vm.closeCells(sp)
+	}
+	vm.sp = sp
+	return result
+}
+
+func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) object.Object {
+	args := make([]object.Object, numArgs)
+	copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+
+	result := builtin.Fn(vm.ctx, args...)
+	vm.sp = vm.sp - numArgs - 1
+
+	return vm.push(result)
+}
+
+// callClass builds a new instance of a class, binding the arguments as its fields
+// before running the constructor on it
+func (vm *VM) callClass(class *object.Class, numArgs int) object.Object {
+	if class.Constructor == nil {
+		return evaluator.NewError("not a function: %s", class.Type())
+	}
+	if err := evaluator.CheckArity(class.Name.Value, numArgs, len(class.Parameters)); err != nil {
+		return err
+	}
+
+	env := object.NewEnvironment()
+	for i, param := range class.Parameters {
+		env.Set(param.Value, vm.stack[vm.sp-numArgs+i])
+	}
+	instance := &object.Instance{Class: class, Env: env}
+	instance.This = instance
+
+	return vm.callClosure(class.Constructor, numArgs, instance)
+}
+
+// callSuper runs the parent class's constructor on a new layer of the instance being built
+func (vm *VM) callSuper(receiver *object.Instance, numArgs int) object.Object {
+	parent := receiver.Class.Parent
+	if err := evaluator.CheckArity(parent.Name.Value, numArgs, len(parent.Parameters)); err != nil {
+		return err
+	}
+
+	env := object.NewEnvironment()
+	for i, param := range parent.Parameters {
+		env.Set(param.Value, vm.stack[vm.sp-numArgs+i])
+	}
+	layer := &object.Instance{Class: parent, Env: env, This: receiver.This}
+
+	return vm.callClosure(parent.Constructor, numArgs, layer)
+}
+
+func (vm *VM) pushClosure(constIndex int, numFree int, receiver *object.Instance) object.Object {
+	function := vm.constants[constIndex].(*object.CompiledFunction)
+
+	free := make([]*object.Cell, numFree)
+	for i, val := range vm.stack[vm.sp-numFree : vm.sp] {
+		// anything but a variable, like the closure a function refers to itself by, can't change
+		cell, ok := val.(*object.Cell)
+		if !ok {
+			cell = object.ClosedCell(val)
+		}
+		free[i] = cell
+	}
+	vm.sp = vm.sp - numFree
+
+	return vm.push(&object.Closure{Fn: function, Free: free, Receiver: receiver})
+}
+
+// cell gives the cell for the variable in a slot of the stack, which every closure
+// capturing the variable shares
+func (vm *VM) cell(slot int) *object.Cell {
+	if c, ok := vm.openCells[slot]; ok {
+		return c
+	}
+	c := object.NewCell(&vm.stack[slot])
+	vm.openCells[slot] = c
+	return c
+}
+
+// closeCells closes the cells for slots from base up, as the stack above base is
+// about to be reused
+func (vm *VM) closeCells(base int) {
+	for slot, c := range vm.openCells {
+		if slot >= base {
+			c.Close()
+			delete(vm.openCells, slot)
+		}
+	}
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
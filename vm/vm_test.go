@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/OisinA/Azula/compiler"
+	"github.com/OisinA/Azula/lexer"
+	"github.com/OisinA/Azula/object"
+	"github.com/OisinA/Azula/parser"
+	"github.com/OisinA/Azula/testsuite"
+)
+
+func testRun(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	comp := compiler.New()
+	if err := comp.CompileProgram(program); err != nil {
+		compileErr := err.(*compiler.Error)
+		return &object.Error{Message: compileErr.Message, Pos: compileErr.Pos}
+	}
+
+	return New(comp.Bytecode()).Run()
+}
+
+func TestBackendSuite(t *testing.T) {
+	testsuite.Run(t, testRun)
+}
+
+func TestBackendIO(t *testing.T) {
+	testsuite.RunIO(t, func(input string, ctx *object.Context) object.Object {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		comp := compiler.New()
+		if err := comp.CompileProgram(program); err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		machine := New(comp.Bytecode())
+		machine.SetContext(ctx)
+		return machine.Run()
+	})
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"foobar", 1, 1},
+		{"int x = 5;\nx - true;", 2, 3},
+		{"func f(int a): int {\n\treturn a - true;\n}\nf(1);", 2, 11},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testRun(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Pos.Line != tt.expectedLine || errObj.Pos.Column != tt.expectedColumn {
+			t.Errorf("wrong error position. expected=%d:%d, got=%d:%d", tt.expectedLine, tt.expectedColumn, errObj.Pos.Line, errObj.Pos.Column)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxDepth int
+		maxSteps int
+		expected string
+	}{
+		{"func f(int n): int { return f(n + 1); } f(0);", 10, 0, "maximum recursion depth exceeded in f"},
+		{"func f(int n): int { if(n == 0) { return 0; } return f(n - 1); } f(10);", 11, 0, ""},
+		{"func f(int n): int { if(n == 0) { return 0; } return f(n - 1); } f(10);", 10, 0, "maximum recursion depth exceeded in f"},
+		{"func f(int n): int { if(n == 0) { return 0; } return f(n - 1); } f(2000);", 0, 0, ""},
+		{"while(true) { 1; }", 0, 1000, "maximum number of steps exceeded (1000)"},
+		{"while(true) { try { 1; } catch (e) { 2; } }", 0, 1000, "maximum number of steps exceeded (1000)"},
+		{"int i = 0; while(i < 10) { i = i + 1; }", 0, 1000, ""},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		comp := compiler.New()
+		if err := comp.CompileProgram(program); err != nil {
+			t.Fatalf("%q: compiler error: %s", tt.input, err)
+		}
+		machine := New(comp.Bytecode())
+		machine.SetLimits(&object.Limits{MaxDepth: tt.maxDepth, MaxSteps: tt.maxSteps})
+		result := machine.Run()
+
+		errObj, ok := result.(*object.Error)
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("%q: unexpected error %q", tt.input, errObj.Message)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, result, result)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}